@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+type EventType string
+
+const (
+	EnrollmentEvent            EventType = "enrollment"
+	VerificationSucceededEvent EventType = "verification_succeeded"
+	VerificationFailedEvent    EventType = "verification_failed"
+	RecoveryCodeUsedEvent      EventType = "recovery_code_used"
+	LockoutEvent               EventType = "lockout"
+	SecretRotatedEvent         EventType = "secret_rotated"
+	CredentialDeletedEvent     EventType = "credential_deleted"
+)
+
+// Event is a single MFA audit record, it only holds the key metadata and
+// never the secret or the codes.
+type Event struct {
+	Time         time.Time         `json:"time"`
+	Type         EventType         `json:"type"`
+	UserId       string            `json:"user_id,omitempty"`
+	Issuer       string            `json:"issuer,omitempty"`
+	KeyType      string            `json:"key_type,omitempty"`
+	CredentialId string            `json:"credential_id,omitempty"`
+	Details      map[string]string `json:"details,omitempty"`
+}
+
+func NewEvent(eventType EventType, userId string, t time.Time) Event {
+	return Event{
+		Time:   t.UTC(),
+		Type:   eventType,
+		UserId: userId,
+	}
+}
+
+// NewKeyEvent creates an event filled with the issuer, user and type of the key.
+func NewKeyEvent(eventType EventType, key *otp.OtpKey, t time.Time) Event {
+	event := NewEvent(eventType, "", t)
+	if key != nil {
+		event.UserId = key.UserId()
+		event.Issuer = key.Issuer()
+		event.KeyType = key.Type()
+	}
+
+	return event
+}
+
+// AuditSink stores the events, implementations writing to a database or a
+// remote service should honour the context deadline.
+type AuditSink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+type MemorySink struct {
+	lock   sync.Mutex
+	events []Event
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Write(ctx context.Context, event Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.events = append(s.events, event)
+
+	return nil
+}
+
+func (s *MemorySink) Events() []Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]Event{}, s.events...)
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyEvent(t *testing.T) {
+	key, err := otp.GenerateKey("totp", otp.NewDefaultOtpKeyOptions("foobar", "foobar@example.com"))
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("test", 3600))
+
+	event := NewKeyEvent(EnrollmentEvent, key, now)
+
+	assert.Equal(t, EnrollmentEvent, event.Type)
+	assert.Equal(t, "foobar", event.Issuer)
+	assert.Equal(t, "foobar@example.com", event.UserId)
+	assert.Equal(t, "totp", event.KeyType)
+	assert.Equal(t, time.UTC, event.Time.Location())
+	assert.True(t, now.Equal(event.Time))
+
+	event = NewKeyEvent(LockoutEvent, nil, now)
+	assert.Equal(t, "", event.UserId)
+}
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+
+	require.NoError(t, sink.Write(context.Background(), NewEvent(LockoutEvent, "foobar@example.com", time.Now())))
+	require.NoError(t, sink.Write(context.Background(), NewEvent(RecoveryCodeUsedEvent, "foobar@example.com", time.Now())))
+
+	events := sink.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, LockoutEvent, events[0].Type)
+	assert.Equal(t, RecoveryCodeUsedEvent, events[1].Type)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, sink.Write(ctx, NewEvent(LockoutEvent, "foobar@example.com", time.Now())), context.Canceled)
+	assert.Len(t, sink.Events(), 2)
+}
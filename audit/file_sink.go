@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+// Record is a line of the audit file, each record hash covers the previous
+// record hash so editing, removing or reordering lines breaks the chain.
+type Record struct {
+	Sequence uint64 `json:"seq"`
+	Event    Event  `json:"event"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+func (r Record) computeHash() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FileSink appends the events to a hash-chained JSON lines file.
+type FileSink struct {
+	lock     sync.Mutex
+	file     *os.File
+	sequence uint64
+	lastHash string
+}
+
+// NewFileSink opens or creates the audit file, an existing file is verified
+// before appending so a tampered log is never extended.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := verify(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	result := FileSink{
+		file: file,
+	}
+	if last != nil {
+		result.sequence = last.Sequence
+		result.lastHash = last.Hash
+	}
+
+	return &result, nil
+}
+
+// Write appends the event, nothing is written once ctx is done.
+func (s *FileSink) Write(ctx context.Context, event Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	event.Time = event.Time.UTC()
+	record := Record{
+		Sequence: s.sequence + 1,
+		Event:    event,
+		PrevHash: s.lastHash,
+	}
+
+	hash, err := record.computeHash()
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+
+	s.sequence = record.Sequence
+	s.lastHash = record.Hash
+
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.file.Close()
+}
+
+// Verify reads a whole audit log checking the hash chain, it returns an
+// ErrorAuditTampered error naming the first line that does not match.
+func Verify(r io.Reader) error {
+	_, err := verify(r)
+	return err
+}
+
+func verify(r io.Reader) (*Record, error) {
+	var last *Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		field := fmt.Sprintf("line %d", line)
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, common.ErrorAuditTampered.WithField(field).WithCause(err)
+		}
+
+		expectedSequence := uint64(1)
+		expectedPrevHash := ""
+		if last != nil {
+			expectedSequence = last.Sequence + 1
+			expectedPrevHash = last.Hash
+		}
+
+		if record.Sequence != expectedSequence || record.PrevHash != expectedPrevHash {
+			return nil, common.ErrorAuditTampered.WithField(field)
+		}
+
+		hash, err := record.computeHash()
+		if err != nil {
+			return nil, err
+		}
+
+		if hash != record.Hash {
+			return nil, common.ErrorAuditTampered.WithField(field)
+		}
+
+		last = &record
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return last, nil
+}
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAuditLog(t *testing.T, count int) string {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	for i := 0; i < count; i++ {
+		event := NewEvent(VerificationFailedEvent, "foobar@example.com", time.Now())
+		event.Details = map[string]string{"attempt": strings.Repeat("x", i+1)}
+		require.NoError(t, sink.Write(context.Background(), event))
+	}
+	require.NoError(t, sink.Close())
+
+	return path
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestFileSinkChainsRecords(t *testing.T) {
+	path := writeAuditLog(t, 3)
+
+	lines := readLines(t, path)
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"seq":1`)
+	assert.Contains(t, lines[0], `"prev_hash":""`)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	assert.NoError(t, Verify(file))
+}
+
+func TestFileSinkContinuesExistingChain(t *testing.T) {
+	path := writeAuditLog(t, 2)
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), NewEvent(LockoutEvent, "foobar@example.com", time.Now())))
+	require.NoError(t, sink.Close())
+
+	lines := readLines(t, path)
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], `"seq":3`)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NoError(t, Verify(bytes.NewReader(data)))
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		line   string
+	}{
+		{
+			"edited event",
+			func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], "verification_failed", "verification_succeeded", 1)
+				return lines
+			},
+			"line 2",
+		},
+		{
+			"removed line",
+			func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			"line 2",
+		},
+		{
+			"reordered lines",
+			func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			"line 2",
+		},
+		{
+			"invalid json",
+			func(lines []string) []string {
+				lines[2] = "{"
+				return lines
+			},
+			"line 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeAuditLog(t, 3)
+			lines := tt.tamper(readLines(t, path))
+			require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			err = Verify(bytes.NewReader(data))
+			assert.ErrorIs(t, err, common.ErrorAuditTampered)
+			assert.Contains(t, err.Error(), tt.line)
+
+			sink, err := NewFileSink(path)
+			assert.ErrorIs(t, err, common.ErrorAuditTampered)
+			assert.Nil(t, sink)
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	aegisVersion       = 1
+	aegisDbVersion     = 2
+	aegisPasswordSlot  = 1
+	aegisMasterKeySize = 32
+
+	// the scrypt parameters come from the file, they are capped so a
+	// crafted file can not make parsing it use unbounded memory or time,
+	// Aegis itself uses N = 2^15, r = 8 and p = 1
+	aegisMaxScryptN = 1 << 20
+	aegisMaxScryptR = 32
+	aegisMaxScryptP = 16
+)
+
+// aegisAlgorithms are the algorithms Aegis can generate codes with.
+var aegisAlgorithms = []common.Algorithm{common.SHA1Algorithm, common.SHA256Algorithm, common.SHA512Algorithm}
+
+type aegisFile struct {
+	Version int             `json:"version"`
+	Header  aegisHeader     `json:"header"`
+	Db      json.RawMessage `json:"db"`
+}
+
+type aegisHeader struct {
+	Slots  []aegisSlot     `json:"slots"`
+	Params *aegisKeyParams `json:"params"`
+}
+
+type aegisSlot struct {
+	Type      int            `json:"type"`
+	UUID      string         `json:"uuid"`
+	Key       string         `json:"key"`
+	KeyParams aegisKeyParams `json:"key_params"`
+	N         int            `json:"n"`
+	R         int            `json:"r"`
+	P         int            `json:"p"`
+	Salt      string         `json:"salt"`
+}
+
+type aegisKeyParams struct {
+	Nonce string `json:"nonce"`
+	Tag   string `json:"tag"`
+}
+
+type aegisDb struct {
+	Version int          `json:"version"`
+	Entries []aegisEntry `json:"entries"`
+	Groups  []aegisGroup `json:"groups,omitempty"`
+}
+
+type aegisGroup struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+type aegisEntry struct {
+	Type     string    `json:"type"`
+	UUID     string    `json:"uuid"`
+	Name     string    `json:"name"`
+	Issuer   string    `json:"issuer"`
+	Note     string    `json:"note"`
+	Favorite bool      `json:"favorite"`
+	Icon     *string   `json:"icon"`
+	Group    *string   `json:"group,omitempty"`
+	Groups   []string  `json:"groups,omitempty"`
+	Info     aegisInfo `json:"info"`
+}
+
+type aegisInfo struct {
+	Secret  string  `json:"secret"`
+	Algo    string  `json:"algo"`
+	Digits  int     `json:"digits"`
+	Period  uint    `json:"period,omitempty"`
+	Counter *uint64 `json:"counter,omitempty"`
+}
+
+// ParseAegis reads an Aegis json vault, the password is only used when the
+// vault is encrypted. Entries of types other than TOTP and HOTP are skipped.
+func ParseAegis(data []byte, password string) ([]Entry, error) {
+	var file aegisFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("aegis").WithCause(err)
+	}
+
+	if file.Version != aegisVersion {
+		return nil, common.ErrorInvalidEncoding.WithField("version").WithCause(fmt.Errorf("unsupported aegis vault version %d", file.Version))
+	}
+
+	dbJson := []byte(file.Db)
+	if file.Header.Params != nil {
+		var err error
+		if dbJson, err = decryptAegisDb(file, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var db aegisDb
+	if err := json.Unmarshal(dbJson, &db); err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("db").WithCause(err)
+	}
+
+	groups := make(map[string]string, len(db.Groups))
+	for _, group := range db.Groups {
+		groups[group.UUID] = group.Name
+	}
+
+	result := make([]Entry, 0, len(db.Entries))
+	for i, entry := range db.Entries {
+		if skipUnsupported(entry.Type, i) {
+			continue
+		}
+
+		info := keyInfo{
+			keyType:   entry.Type,
+			issuer:    entry.Issuer,
+			name:      entry.Name,
+			secret:    entry.Info.Secret,
+			algorithm: entry.Info.Algo,
+			digits:    entry.Info.Digits,
+			period:    entry.Info.Period,
+		}
+		if entry.Info.Counter != nil {
+			info.counter = *entry.Info.Counter
+		}
+
+		key, err := newKey(info)
+		if err != nil {
+			return nil, common.WithField(err, entryField(i))
+		}
+
+		var tags []string
+		if entry.Group != nil && *entry.Group != "" {
+			tags = append(tags, *entry.Group)
+		}
+
+		for _, uuid := range entry.Groups {
+			if name, ok := groups[uuid]; ok {
+				tags = append(tags, name)
+			}
+		}
+
+		result = append(result, Entry{Key: key, Tags: tags, Note: entry.Note, Counter: info.counter})
+	}
+
+	return result, nil
+}
+
+// decryptAegisDb unlocks the master key with the first password slot the
+// password opens and decrypts the database with it.
+func decryptAegisDb(file aegisFile, password string) ([]byte, error) {
+	if password == "" {
+		return nil, common.ErrorInvalidPassphrase.WithField("password")
+	}
+
+	var masterKey []byte
+	for _, slot := range file.Header.Slots {
+		if slot.Type != aegisPasswordSlot {
+			continue
+		}
+
+		salt, err := hex.DecodeString(slot.Salt)
+		if err != nil {
+			return nil, common.ErrorInvalidEncoding.WithField("salt").WithCause(err)
+		}
+
+		if slot.N > aegisMaxScryptN || slot.R > aegisMaxScryptR || slot.P > aegisMaxScryptP {
+			return nil, common.ErrorInvalidEncoding.WithField("slots").WithCause(fmt.Errorf("scrypt parameters above n=%d, r=%d, p=%d", aegisMaxScryptN, aegisMaxScryptR, aegisMaxScryptP))
+		}
+
+		key, err := scrypt.Key([]byte(password), salt, slot.N, slot.R, slot.P, aegisMasterKeySize)
+		if err != nil {
+			return nil, common.ErrorInvalidEncoding.WithField("slots").WithCause(err)
+		}
+
+		encrypted, err := hex.DecodeString(slot.Key)
+		if err != nil {
+			return nil, common.ErrorInvalidEncoding.WithField("key").WithCause(err)
+		}
+
+		if masterKey, err = aegisOpen(key, slot.KeyParams, encrypted); err == nil {
+			break
+		}
+	}
+
+	if masterKey == nil {
+		return nil, common.ErrorInvalidPassphrase.WithField("password")
+	}
+
+	var encoded string
+	if err := json.Unmarshal(file.Db, &encoded); err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("db").WithCause(err)
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("db").WithCause(err)
+	}
+
+	result, err := aegisOpen(masterKey, *file.Header.Params, encrypted)
+	if err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("db").WithCause(err)
+	}
+
+	return result, nil
+}
+
+// aegisOpen decrypts AES-GCM data stored with its tag apart, as Aegis does.
+func aegisOpen(key []byte, params aegisKeyParams, ciphertext []byte) ([]byte, error) {
+	nonce, err := hex.DecodeString(params.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := hex.DecodeString(params.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, nonce, append(ciphertext, tag...), nil)
+}
+
+// ExportAegis writes a plain, unencrypted, Aegis vault that Aegis and this
+// package can import, keys with an algorithm Aegis does not support are
+// rejected. Use VaultEntries to export the current HOTP counters of a vault.
+func ExportAegis(entries []Entry) ([]byte, error) {
+	db := aegisDb{
+		Version: aegisDbVersion,
+		Entries: make([]aegisEntry, 0, len(entries)),
+	}
+
+	for i, entry := range entries {
+		if entry.Key == nil {
+			return nil, common.ErrorNilOtpKey.WithField(entryField(i))
+		}
+
+		options, err := entry.Key.Options()
+		if err != nil {
+			return nil, common.WithField(err, entryField(i))
+		}
+
+		if !slices.Contains(aegisAlgorithms, options.Algorithm) {
+			return nil, common.ErrorUnknownAlgorithm.WithField(entryField(i)).WithCause(fmt.Errorf("aegis does not support %s", options.Algorithm))
+		}
+
+		result := aegisEntry{
+			Type:   entry.Key.Type(),
+			UUID:   newUUID(),
+			Name:   entry.Key.UserId(),
+			Issuer: entry.Key.Issuer(),
+			Note:   entry.Note,
+			Info: aegisInfo{
+				Secret: strings.TrimRight(strings.ToUpper(entry.Key.Secret()), "="),
+				Algo:   options.Algorithm.String(),
+				Digits: options.CodeSize.Length(),
+			},
+		}
+
+		if result.Name == "" {
+			result.Name = strings.TrimPrefix(entry.Key.String(), "otpauth://")
+		}
+
+		if len(entry.Tags) > 0 {
+			result.Group = &entry.Tags[0]
+		}
+
+		switch result.Type {
+		case "totp":
+			if result.Info.Period, err = entry.Key.Period(); err != nil {
+				return nil, common.WithField(err, entryField(i))
+			}
+		case "hotp":
+			counter, err := entry.Key.Counter()
+			if err != nil {
+				return nil, common.WithField(err, entryField(i))
+			}
+			counter = max(counter, entry.Counter)
+			result.Info.Counter = &counter
+		}
+
+		db.Entries = append(db.Entries, result)
+	}
+
+	dbJson, err := json.Marshal(db)
+	if err != nil {
+		return nil, err
+	}
+
+	file := aegisFile{
+		Version: aegisVersion,
+		Db:      dbJson,
+	}
+
+	return json.MarshalIndent(file, "", "    ")
+}
+
+func newUUID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/cjlapao/common-go-identity-otp/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/scrypt"
+)
+
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+const aegisPlainDb = `{
+	"version": 3,
+	"entries": [
+		{
+			"type": "totp",
+			"uuid": "3ae6f1ad-2e65-4ed2-a953-1ec0dff2386d",
+			"name": "octocat",
+			"issuer": "GitHub",
+			"note": "recovery codes in the safe",
+			"favorite": true,
+			"icon": null,
+			"groups": ["0c9fdcda-7a4b-4c59-b8fb-f4f8c6c0b9b6"],
+			"info": {"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "algo": "SHA1", "digits": 6, "period": 30}
+		},
+		{
+			"type": "hotp",
+			"uuid": "b6a7d1c2-0e0b-4d76-8f5e-2f5f3b7c9a10",
+			"name": "john",
+			"issuer": "Bank",
+			"note": "",
+			"favorite": false,
+			"icon": null,
+			"info": {"secret": "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", "algo": "SHA1", "digits": 8, "counter": 7}
+		},
+		{
+			"type": "steam",
+			"uuid": "5d2f7e2a-7c1e-4d0f-9a6e-8d0b9c3f1e22",
+			"name": "gamer",
+			"issuer": "Steam",
+			"info": {"secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "algo": "SHA1", "digits": 5, "period": 30}
+		}
+	],
+	"groups": [{"uuid": "0c9fdcda-7a4b-4c59-b8fb-f4f8c6c0b9b6", "name": "Work"}]
+}`
+
+func plainAegis(db string) []byte {
+	return []byte(`{"version": 1, "header": {"slots": null, "params": null}, "db": ` + db + `}`)
+}
+
+func aegisSeal(t *testing.T, key []byte, plaintext []byte) ([]byte, map[string]string) {
+	nonce := make([]byte, 12)
+	_, err := rand.Read(nonce)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	sealed := aead.Seal(nil, nonce, plaintext, nil)
+	ciphertext, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+
+	return ciphertext, map[string]string{"nonce": hex.EncodeToString(nonce), "tag": hex.EncodeToString(tag)}
+}
+
+// encryptedAegis builds an encrypted vault the way Aegis does, a random
+// master key wrapped by an scrypt password slot.
+func encryptedAegis(t *testing.T, db string, password string) []byte {
+	return encryptedAegisWithScrypt(t, db, password, 1024, 8, 1)
+}
+
+func encryptedAegisWithScrypt(t *testing.T, db string, password string, n int, r int, p int) []byte {
+	masterKey := make([]byte, 32)
+	salt := make([]byte, 32)
+	_, _ = rand.Read(masterKey)
+	_, _ = rand.Read(salt)
+
+	slotKey, err := scrypt.Key([]byte(password), salt, 1024, 8, 1, 32)
+	require.NoError(t, err)
+
+	wrapped, keyParams := aegisSeal(t, slotKey, masterKey)
+	encryptedDb, params := aegisSeal(t, masterKey, []byte(db))
+
+	file := map[string]any{
+		"version": 1,
+		"header": map[string]any{
+			"slots": []map[string]any{
+				{"type": 0, "uuid": "raw", "key": "00", "key_params": keyParams},
+				{
+					"type": 1, "uuid": "password", "key": hex.EncodeToString(wrapped), "key_params": keyParams,
+					"n": n, "r": r, "p": p, "salt": hex.EncodeToString(salt),
+				},
+			},
+			"params": params,
+		},
+		"db": base64.StdEncoding.EncodeToString(encryptedDb),
+	}
+
+	data, err := json.Marshal(file)
+	require.NoError(t, err)
+
+	return data
+}
+
+func assertAegisEntries(t *testing.T, entries []Entry) {
+	require.Len(t, entries, 2)
+
+	github := entries[0]
+	assert.Equal(t, "totp", github.Key.Type())
+	assert.Equal(t, "GitHub", github.Key.Issuer())
+	assert.Equal(t, "octocat", github.Key.UserId())
+	assert.Equal(t, []string{"Work"}, github.Tags)
+	assert.Equal(t, "recovery codes in the safe", github.Note)
+
+	code, err := totp.GenerateCode(github.Key.Secret(), time.Unix(59, 0), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	bank := entries[1]
+	assert.Equal(t, "hotp", bank.Key.Type())
+	digits, err := bank.Key.Digits()
+	require.NoError(t, err)
+	assert.Equal(t, common.EightDigits, digits)
+	counter, err := bank.Key.Counter()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), counter)
+	assert.Equal(t, uint64(7), bank.Counter)
+
+	code, err = hotp.GenerateCode(bank.Key.Secret(), 0, &otp.OtpOptions{CodeSize: common.SixDigits})
+	require.NoError(t, err)
+	assert.Equal(t, "755224", code)
+}
+
+func TestParsePlainAegis(t *testing.T) {
+	entries, err := ParseAegis(plainAegis(aegisPlainDb), "")
+	require.NoError(t, err)
+	assertAegisEntries(t, entries)
+}
+
+func TestParseEncryptedAegis(t *testing.T) {
+	data := encryptedAegis(t, aegisPlainDb, "correct horse")
+
+	entries, err := ParseAegis(data, "correct horse")
+	require.NoError(t, err)
+	assertAegisEntries(t, entries)
+
+	_, err = ParseAegis(data, "battery staple")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+
+	_, err = ParseAegis(data, "")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+}
+
+func TestParseAegisErrors(t *testing.T) {
+	_, err := ParseAegis([]byte(`{"version": 2}`), "")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+
+	_, err = ParseAegis([]byte(`[]`), "")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+
+	_, err = ParseAegis(plainAegis(`{"version": 2, "entries": [{"type": "totp", "name": "a", "info": {"secret": "1!1"}}]}`), "")
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+	assert.ErrorContains(t, err, "entries[0]")
+
+	_, err = ParseAegis(plainAegis(`{"version": 2, "entries": [{"type": "totp", "name": "a", "info": {"secret": "`+rfcSecret+`", "algo": "MD5"}}]}`), "")
+	assert.ErrorIs(t, err, common.ErrorUnknownAlgorithm)
+}
+
+func TestParseAegisExcessiveScryptParams(t *testing.T) {
+	tests := map[string][3]int{
+		"n": {1 << 21, 8, 1},
+		"r": {1024, 64, 1},
+		"p": {1024, 8, 32},
+	}
+
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := encryptedAegisWithScrypt(t, aegisPlainDb, "correct horse", params[0], params[1], params[2])
+
+			_, err := ParseAegis(data, "correct horse")
+			assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+			assert.ErrorContains(t, err, "slots")
+		})
+	}
+}
+
+func TestAegisRoundTrip(t *testing.T) {
+	entries, err := ParseAegis(plainAegis(aegisPlainDb), "")
+	require.NoError(t, err)
+
+	data, err := ExportAegis(entries)
+	require.NoError(t, err)
+
+	var file map[string]any
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Equal(t, map[string]any{"slots": nil, "params": nil}, file["header"])
+
+	imported, err := ParseAegis(data, "")
+	require.NoError(t, err)
+	require.Len(t, imported, len(entries))
+	for i := range entries {
+		assert.Equal(t, entries[i].Key.String(), imported[i].Key.String())
+		assert.Equal(t, entries[i].Tags, imported[i].Tags)
+		assert.Equal(t, entries[i].Note, imported[i].Note)
+	}
+
+	key, err := totp.GenerateDefaultKey("Example", "me@example.com")
+	require.NoError(t, err)
+
+	data, err = ExportAegis([]Entry{{Key: key}})
+	require.NoError(t, err)
+	imported, err = ParseAegis(data, "")
+	require.NoError(t, err)
+	assert.Equal(t, key.Secret(), imported[0].Key.Secret())
+	assert.Equal(t, key.UserId(), imported[0].Key.UserId())
+
+	_, err = ExportAegis([]Entry{{}})
+	assert.ErrorIs(t, err, common.ErrorNilOtpKey)
+}
+
+func TestExportAegisVaultCounters(t *testing.T) {
+	accounts := vault.New()
+	key, err := otp.ParseKey("otpauth://hotp/Bank:john?issuer=Bank&counter=2&secret=" + rfcSecret)
+	require.NoError(t, err)
+	account, err := accounts.Add(key, "money")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := accounts.Code(account.ID, time.Now())
+		require.NoError(t, err)
+	}
+
+	data, err := ExportAegis(VaultEntries(accounts.Accounts()))
+	require.NoError(t, err)
+
+	imported, err := ParseAegis(data, "")
+	require.NoError(t, err)
+	require.Len(t, imported, 1)
+	assert.Equal(t, uint64(5), imported[0].Counter)
+	assert.Equal(t, []string{"money"}, imported[0].Tags)
+
+	counter, err := imported[0].Key.Counter()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), counter)
+}
+
+func TestExportAegisUnsupportedAlgorithm(t *testing.T) {
+	key, err := otp.ParseKey("otpauth://totp/Example:me?algorithm=SHA3-256&secret=" + rfcSecret)
+	require.NoError(t, err)
+
+	_, err = ExportAegis([]Entry{{Key: key}})
+	assert.ErrorIs(t, err, common.ErrorUnknownAlgorithm)
+	assert.ErrorContains(t, err, "entries[0]")
+}
@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	andOtpIterationsSize = 4
+	andOtpSaltSize       = 12
+	andOtpNonceSize      = 12
+	andOtpKeySize        = 32
+
+	// andOTP uses between 140000 and 160000 iterations, the count comes from
+	// the file and is capped so a crafted file can not hang the parser
+	andOtpMaxIterations = 1000000
+)
+
+type andOtpEntry struct {
+	Secret    string   `json:"secret"`
+	Issuer    string   `json:"issuer"`
+	Label     string   `json:"label"`
+	Digits    int      `json:"digits"`
+	Type      string   `json:"type"`
+	Algorithm string   `json:"algorithm"`
+	Period    uint     `json:"period"`
+	Counter   uint64   `json:"counter"`
+	Tags      []string `json:"tags"`
+}
+
+// ParseAndOTP reads an andOTP backup, a plain json one when password is
+// empty and a password encrypted one otherwise. Entries of types other than
+// TOTP and HOTP are skipped.
+func ParseAndOTP(data []byte, password string) ([]Entry, error) {
+	if password != "" {
+		var err error
+		if data, err = decryptAndOTP(data, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []andOtpEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("andotp").WithCause(err)
+	}
+
+	result := make([]Entry, 0, len(entries))
+	for i, entry := range entries {
+		if skipUnsupported(entry.Type, i) {
+			continue
+		}
+
+		issuer, name := entry.Issuer, entry.Label
+		// older backups only have the label, as issuer:name
+		if sep := strings.Index(name, ":"); issuer == "" && sep != -1 {
+			issuer, name = strings.TrimSpace(name[:sep]), strings.TrimSpace(name[sep+1:])
+		}
+
+		key, err := newKey(keyInfo{
+			keyType:   entry.Type,
+			issuer:    issuer,
+			name:      name,
+			secret:    entry.Secret,
+			algorithm: entry.Algorithm,
+			digits:    entry.Digits,
+			period:    entry.Period,
+			counter:   entry.Counter,
+		})
+		if err != nil {
+			return nil, common.WithField(err, entryField(i))
+		}
+
+		result = append(result, Entry{Key: key, Tags: entry.Tags, Counter: entry.Counter})
+	}
+
+	return result, nil
+}
+
+// decryptAndOTP opens the password backup format, the PBKDF2 iterations and
+// salt are followed by the AES-GCM nonce and ciphertext.
+func decryptAndOTP(data []byte, password string) ([]byte, error) {
+	headerSize := andOtpIterationsSize + andOtpSaltSize + andOtpNonceSize
+	if len(data) <= headerSize {
+		return nil, common.ErrorInvalidEncoding.WithField("andotp")
+	}
+
+	iterations := binary.BigEndian.Uint32(data[:andOtpIterationsSize])
+	salt := data[andOtpIterationsSize : andOtpIterationsSize+andOtpSaltSize]
+	nonce := data[andOtpIterationsSize+andOtpSaltSize : headerSize]
+	if iterations == 0 || iterations > andOtpMaxIterations {
+		return nil, common.ErrorInvalidEncoding.WithField("iterations").WithCause(fmt.Errorf("must be between 1 and %d", andOtpMaxIterations))
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, int(iterations), andOtpKeySize, sha1.New)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := aead.Open(nil, nonce, data[headerSize:], nil)
+	if err != nil {
+		return nil, common.ErrorInvalidPassphrase.WithField("password")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var andOtpPlain = `[
+	{"secret": "` + rfcSecret + `", "issuer": "GitHub", "label": "octocat", "digits": 6, "type": "TOTP",
+	 "algorithm": "SHA1", "thumbnail": "Github", "last_used": 1700000000000, "used_frequency": 3, "period": 30, "tags": ["work"]},
+	{"secret": "` + rfcSecret + `", "issuer": "", "label": "Bank:john", "digits": 8, "type": "HOTP",
+	 "algorithm": "SHA256", "counter": 3, "tags": []},
+	{"secret": "` + rfcSecret + `", "issuer": "Steam", "label": "gamer", "digits": 5, "type": "STEAM", "algorithm": "SHA1", "period": 30}
+]`
+
+// encryptedAndOTP builds a backup in the andOTP password format.
+func encryptedAndOTP(t *testing.T, plaintext string, password string) []byte {
+	header := make([]byte, andOtpIterationsSize+andOtpSaltSize+andOtpNonceSize)
+	binary.BigEndian.PutUint32(header, 1000)
+	_, err := rand.Read(header[andOtpIterationsSize:])
+	require.NoError(t, err)
+
+	key := pbkdf2.Key([]byte(password), header[andOtpIterationsSize:andOtpIterationsSize+andOtpSaltSize], 1000, andOtpKeySize, sha1.New)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	return aead.Seal(header, header[andOtpIterationsSize+andOtpSaltSize:], []byte(plaintext), nil)
+}
+
+func assertAndOtpEntries(t *testing.T, entries []Entry) {
+	require.Len(t, entries, 2)
+
+	github := entries[0]
+	assert.Equal(t, "totp", github.Key.Type())
+	assert.Equal(t, "GitHub", github.Key.Issuer())
+	assert.Equal(t, []string{"work"}, github.Tags)
+
+	code, err := totp.GenerateCode(github.Key.Secret(), time.Unix(59, 0), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	bank := entries[1]
+	assert.Equal(t, "hotp", bank.Key.Type())
+	assert.Equal(t, "Bank", bank.Key.Issuer())
+	assert.Equal(t, "john", bank.Key.UserId())
+	algorithm, err := bank.Key.HashAlgorithm()
+	require.NoError(t, err)
+	assert.Equal(t, common.SHA256Algorithm, algorithm)
+	counter, err := bank.Key.Counter()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), counter)
+}
+
+func TestParsePlainAndOTP(t *testing.T) {
+	entries, err := ParseAndOTP([]byte(andOtpPlain), "")
+	require.NoError(t, err)
+	assertAndOtpEntries(t, entries)
+}
+
+func TestParseEncryptedAndOTP(t *testing.T) {
+	data := encryptedAndOTP(t, andOtpPlain, "correct horse")
+
+	entries, err := ParseAndOTP(data, "correct horse")
+	require.NoError(t, err)
+	assertAndOtpEntries(t, entries)
+
+	_, err = ParseAndOTP(data, "battery staple")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+
+	_, err = ParseAndOTP(data[:10], "correct horse")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+
+	_, err = ParseAndOTP(data, "")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+
+	oversized := append([]byte(nil), data...)
+	binary.BigEndian.PutUint32(oversized, 0xffffffff)
+	_, err = ParseAndOTP(oversized, "correct horse")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+	assert.ErrorContains(t, err, "iterations")
+}
+
+func TestAndOTPToAegis(t *testing.T) {
+	entries, err := ParseAndOTP([]byte(andOtpPlain), "")
+	require.NoError(t, err)
+
+	data, err := ExportAegis(entries)
+	require.NoError(t, err)
+
+	imported, err := ParseAegis(data, "")
+	require.NoError(t, err)
+	assert.Equal(t, Keys(entries)[0].String(), Keys(imported)[0].String())
+	assert.Equal(t, Keys(entries)[1].String(), Keys(imported)[1].String())
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/helpers"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/vault"
+)
+
+// Entry is an account read from, or written to, another authenticator's
+// backup, Tags holds its groups or tags.
+type Entry struct {
+	Key  *otp.OtpKey
+	Tags []string
+	Note string
+	// Counter is the next HOTP counter, the counter of the key uri is the
+	// one the account was enrolled with and is exported when it is larger.
+	Counter uint64
+}
+
+// VaultEntries exports the accounts of a vault with their current HOTP
+// counters.
+func VaultEntries(accounts []*vault.Account) []Entry {
+	result := make([]Entry, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, Entry{
+			Key:     account.Key,
+			Tags:    slices.Clone(account.Tags),
+			Counter: account.Counter,
+		})
+	}
+
+	return result
+}
+
+func Keys(entries []Entry) []*otp.OtpKey {
+	result := make([]*otp.OtpKey, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.Key)
+	}
+
+	return result
+}
+
+type keyInfo struct {
+	keyType   string
+	issuer    string
+	name      string
+	secret    string
+	algorithm string
+	digits    int
+	period    uint
+	counter   uint64
+}
+
+// newKey builds and validates the otpauth uri of an imported account.
+func newKey(info keyInfo) (*otp.OtpKey, error) {
+	keyType := strings.ToLower(info.keyType)
+	if keyType != "totp" && keyType != "hotp" {
+		return nil, common.ErrorUnsupportedKeyType.WithField("type")
+	}
+
+	algorithm := common.SHA1Algorithm
+	if info.algorithm != "" {
+		var err error
+		if algorithm, err = common.ParseAlgorithm(info.algorithm); err != nil {
+			return nil, common.WithField(err, "algorithm")
+		}
+	}
+
+	codeSize := common.SixDigits
+	if info.digits != 0 {
+		var err error
+		if codeSize, err = common.NewPassCodeSize(info.digits); err != nil {
+			return nil, common.WithField(err, "digits")
+		}
+	}
+
+	values := url.Values{}
+	values.Set("secret", strings.TrimRight(helpers.PadSecret(info.secret), "="))
+	values.Set("algorithm", algorithm.String())
+	values.Set("digits", codeSize.String())
+	if info.issuer != "" {
+		values.Set("issuer", info.issuer)
+	}
+
+	if keyType == "totp" && info.period != 0 {
+		values.Set("period", strconv.FormatUint(uint64(info.period), 10))
+	}
+
+	if keyType == "hotp" {
+		values.Set("counter", strconv.FormatUint(info.counter, 10))
+	}
+
+	label := info.name
+	if info.issuer != "" {
+		label = info.issuer + ":" + info.name
+	}
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     keyType,
+		Path:     "/" + label,
+		RawQuery: helpers.EncodeQuery(values),
+	}
+
+	return otp.ParseKey(u.String())
+}
+
+// skipUnsupported reports whether an entry of a type we cannot generate, such
+// as Steam or mOTP, should be left out of the import.
+func skipUnsupported(keyType string, index int) bool {
+	switch strings.ToLower(keyType) {
+	case "totp", "hotp":
+		return false
+	}
+
+	otp.Logger().Warn("skipping unsupported backup entry",
+		slog.String("type", keyType),
+		slog.Int("index", index))
+
+	return true
+}
+
+func entryField(index int) string {
+	return "entries[" + strconv.Itoa(index) + "]"
+}
@@ -0,0 +1,118 @@
+package batch
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/credential"
+)
+
+// Item is a code to check against a credential.
+type Item struct {
+	Credential *credential.Credential
+	Code       string
+}
+
+// ValidationResult is the outcome of a single item, Err is the context
+// error for the items that were not checked before it was cancelled.
+type ValidationResult struct {
+	Credential *credential.Credential
+	Valid      bool
+	Err        error
+}
+
+// Validator checks many codes concurrently with a bounded number of
+// workers, the credential state (counters, drift and last step) is updated
+// exactly like credential.Verifier.VerifyCredential does.
+type Validator struct {
+	// Verifier checks every item, its look ahead, drift and policy settings
+	// apply to the whole batch.
+	Verifier *credential.Verifier
+	// Workers is the maximum number of items checked at the same time.
+	Workers int
+}
+
+func NewValidator() *Validator {
+	result := Validator{
+		Verifier: credential.NewVerifier(),
+		Workers:  runtime.GOMAXPROCS(0),
+	}
+
+	return &result
+}
+
+// Validate returns one result per item in the same order, items sharing a
+// credential are checked one after the other in that order so a counter or
+// time step accepted for one is not accepted again for the next. When the
+// context is cancelled the remaining items are skipped and the context
+// error is returned.
+func (v *Validator) Validate(ctx context.Context, items []Item, t time.Time) ([]ValidationResult, error) {
+	verifier := v.Verifier
+	if verifier == nil {
+		verifier = credential.NewVerifier()
+	}
+
+	results := make([]ValidationResult, len(items))
+	for i, item := range items {
+		results[i].Credential = item.Credential
+	}
+
+	groups := groupByCredential(items)
+	jobs := make(chan []int)
+
+	var wg sync.WaitGroup
+	for range min(max(v.Workers, 1), len(groups)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range jobs {
+				for _, i := range group {
+					if err := ctx.Err(); err != nil {
+						results[i].Err = err
+						continue
+					}
+
+					results[i].Valid, results[i].Err = verifier.VerifyCredentialContext(ctx, items[i].Credential, items[i].Code, t)
+				}
+			}
+		}()
+	}
+
+feed:
+	for g, group := range groups {
+		select {
+		case jobs <- group:
+		case <-ctx.Done():
+			for _, skipped := range groups[g:] {
+				for _, i := range skipped {
+					results[i].Err = ctx.Err()
+				}
+			}
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+// groupByCredential returns the item indexes grouped by credential, in the
+// order each credential first appears.
+func groupByCredential(items []Item) [][]int {
+	var result [][]int
+	seen := make(map[*credential.Credential]int)
+	for i, item := range items {
+		if g, ok := seen[item.Credential]; ok && item.Credential != nil {
+			result[g] = append(result[g], i)
+			continue
+		}
+
+		seen[item.Credential] = len(result)
+		result = append(result, []int{i})
+	}
+
+	return result
+}
@@ -0,0 +1,118 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/credential"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newCredential(t testing.TB, name string) *credential.Credential {
+	key, err := hotp.GenerateKey(&otp.OtpKeyOptions{
+		Issuer:  "Example",
+		UserId:  name,
+		Secret:  otp.NewRandomOtpSecret(20),
+		Options: otp.NewDefaultOtpOptions(),
+	})
+	require.NoError(t, err)
+
+	result, err := credential.NewCredential(name, key)
+	require.NoError(t, err)
+
+	return result
+}
+
+func code(t testing.TB, credential *credential.Credential, counter uint64) string {
+	code, err := hotp.GenerateCode(credential.Key.Secret(), counter, nil)
+	require.NoError(t, err)
+
+	return code
+}
+
+func TestValidate(t *testing.T) {
+	var items []Item
+	for i := 0; i < 100; i++ {
+		voter := newCredential(t, fmt.Sprintf("voter-%d", i))
+		items = append(items, Item{Credential: voter, Code: code(t, voter, 0)})
+	}
+	wrong := []byte(items[42].Code)
+	wrong[0] = '0' + (wrong[0]-'0'+1)%10
+	items[42].Code = string(wrong)
+
+	validator := NewValidator()
+	validator.Workers = 4
+	validator.Verifier.HotpLookAhead = 0
+	results, err := validator.Validate(context.Background(), items, now)
+	require.NoError(t, err)
+	require.Len(t, results, len(items))
+
+	for i, result := range results {
+		assert.Same(t, items[i].Credential, result.Credential)
+		assert.NoError(t, result.Err)
+		assert.Equal(t, i != 42, result.Valid, i)
+	}
+	assert.Equal(t, uint64(1), items[0].Credential.Counter)
+	assert.Equal(t, uint64(0), items[42].Credential.Counter)
+}
+
+func TestValidateSharedCredentialInOrder(t *testing.T) {
+	token := newCredential(t, "token")
+	items := []Item{
+		{Credential: token, Code: code(t, token, 0)},
+		{Credential: token, Code: code(t, token, 0)},
+		{Credential: token, Code: code(t, token, 1)},
+		{Code: "123456"},
+	}
+
+	results, err := NewValidator().Validate(context.Background(), items, now)
+	require.NoError(t, err)
+
+	assert.True(t, results[0].Valid)
+	assert.False(t, results[1].Valid, "a counter can not be used twice")
+	assert.True(t, results[2].Valid)
+	assert.ErrorIs(t, results[3].Err, common.ErrorNilCredential)
+	assert.Equal(t, uint64(2), token.Counter)
+}
+
+func TestValidateCancelled(t *testing.T) {
+	token := newCredential(t, "token")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := NewValidator().Validate(ctx, []Item{{Credential: token, Code: code(t, token, 0)}}, now)
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Valid)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+	assert.Equal(t, uint64(0), token.Counter)
+}
+
+func TestValidateEmpty(t *testing.T) {
+	results, err := (&Validator{}).Validate(context.Background(), nil, now)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func BenchmarkValidate(b *testing.B) {
+	items := make([]Item, 1000)
+	for i := range items {
+		items[i].Credential = newCredential(b, fmt.Sprintf("voter-%d", i))
+		items[i].Code = "000000"
+	}
+	validator := NewValidator()
+	validator.Verifier.HotpLookAhead = 0
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = validator.Validate(context.Background(), items, now)
+	}
+}
@@ -0,0 +1,211 @@
+package codelist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+)
+
+// MaxEntries is the largest list that can be generated.
+const MaxEntries = 5000
+
+// Entry is a single printed code, TOTP entries are valid from ValidFrom
+// until ValidUntil, HOTP entries have no validity and must be used in order.
+type Entry struct {
+	Counter    uint64
+	Code       string
+	ValidFrom  time.Time
+	ValidUntil time.Time
+}
+
+// List is a printable list of the next codes of a key, it holds the codes
+// and must be handed to the user and never stored, the server keeps its
+// Marker instead.
+type List struct {
+	ID        string
+	Issuer    string
+	UserId    string
+	Type      string
+	Entries   []Entry
+	CreatedAt time.Time
+}
+
+// Marker is the server side record of a printed list, it holds no codes and
+// tells how many of them were used from the credential state.
+type Marker struct {
+	ListID    string    `json:"list_id"`
+	Issuer    string    `json:"issuer"`
+	UserId    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	First     uint64    `json:"first"`
+	Last      uint64    `json:"last"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewHotpList generates count codes of a HOTP key starting at counter, the
+// next counter expected by the server.
+func NewHotpList(key *otp.OtpKey, counter uint64, count int, t time.Time) (*List, error) {
+	if err := checkKey(key, "hotp"); err != nil {
+		return nil, err
+	}
+
+	if count <= 0 || count > MaxEntries || counter > ^uint64(0)-uint64(count-1) {
+		return nil, common.ErrorInvalidCodeList.WithField("count")
+	}
+
+	options, err := key.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	result := newList(key, t)
+	for i := uint64(0); i < uint64(count); i++ {
+		attempt := *options
+		code, err := hotp.GenerateCode(key.Secret(), counter+i, &attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Entries = append(result.Entries, Entry{Counter: counter + i, Code: code})
+	}
+
+	return result, nil
+}
+
+// NewTotpList generates the codes of a TOTP key for every time step between
+// from and to, the step containing to is only included when it starts
+// before it.
+func NewTotpList(key *otp.OtpKey, from time.Time, to time.Time, t time.Time) (*List, error) {
+	if err := checkKey(key, "totp"); err != nil {
+		return nil, err
+	}
+
+	period, err := key.Period()
+	if err != nil {
+		return nil, err
+	}
+
+	if from.Unix() < 0 {
+		return nil, common.ErrorInvalidCodeList.WithField("from")
+	}
+
+	if !to.After(from) {
+		return nil, common.ErrorInvalidCodeList.WithField("to")
+	}
+
+	// from is not before the epoch and to is after it, so the last instant
+	// of the range is not either and the steps can not be negative
+	first := from.Unix() / int64(period)
+	last := to.Add(-time.Nanosecond).Unix() / int64(period)
+	if last-first >= MaxEntries {
+		return nil, common.ErrorInvalidCodeList.WithField("to")
+	}
+
+	options, err := key.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	generator, err := totp.NewGenerator(key.Secret(), &totp.TotpOptions{
+		Period:    period,
+		CodeSize:  options.CodeSize,
+		Algorithm: options.Algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	step := time.Duration(period) * time.Second
+	result := newList(key, t)
+	for counter := uint64(first); counter <= uint64(last); counter++ {
+		validFrom := time.Unix(int64(counter*uint64(period)), 0).UTC()
+		code, err := generator.Generate(validFrom)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Entries = append(result.Entries, Entry{
+			Counter:    counter,
+			Code:       code,
+			ValidFrom:  validFrom,
+			ValidUntil: validFrom.Add(step),
+		})
+	}
+
+	return result, nil
+}
+
+func (l *List) Marker() Marker {
+	result := Marker{
+		ListID:    l.ID,
+		Issuer:    l.Issuer,
+		UserId:    l.UserId,
+		Type:      l.Type,
+		CreatedAt: l.CreatedAt,
+	}
+
+	if len(l.Entries) > 0 {
+		result.First = l.Entries[0].Counter
+		result.Last = l.Entries[len(l.Entries)-1].Counter
+	}
+
+	return result
+}
+
+// Used returns how many codes of the list can no longer be used, next is the
+// HOTP counter the server expects or the current TOTP time step.
+func (m *Marker) Used(next uint64) int {
+	switch {
+	case next <= m.First:
+		return 0
+	case next > m.Last:
+		return m.size()
+	default:
+		return int(next - m.First)
+	}
+}
+
+func (m *Marker) Remaining(next uint64) int {
+	return m.size() - m.Used(next)
+}
+
+// Exhausted reports whether the user needs a new list.
+func (m *Marker) Exhausted(next uint64) bool {
+	return m.Remaining(next) == 0
+}
+
+func (m *Marker) size() int {
+	return int(m.Last-m.First) + 1
+}
+
+func checkKey(key *otp.OtpKey, keyType string) error {
+	if key == nil {
+		return common.ErrorNilOtpKey.WithField("key")
+	}
+
+	if key.Type() != keyType {
+		return common.ErrorUnsupportedKeyType.WithField("key")
+	}
+
+	return nil
+}
+
+func newList(key *otp.OtpKey, t time.Time) *List {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	result := List{
+		ID:        hex.EncodeToString(id),
+		Issuer:    key.Issuer(),
+		UserId:    key.UserId(),
+		Type:      key.Type(),
+		CreatedAt: t,
+	}
+
+	return &result
+}
@@ -0,0 +1,146 @@
+package codelist
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sha1Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now        = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func newKey(t *testing.T, keyType string) *otp.OtpKey {
+	options := &otp.OtpKeyOptions{
+		Issuer:  "Example",
+		UserId:  "foobar@example.com",
+		Secret:  otp.NewSecret(sha1Secret),
+		Options: otp.NewDefaultOtpOptions(),
+	}
+
+	generate := hotp.GenerateKey
+	if keyType == "totp" {
+		generate = totp.GenerateKey
+	}
+
+	key, err := generate(options)
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestNewHotpList(t *testing.T) {
+	list, err := NewHotpList(newKey(t, "hotp"), 3, 5, now)
+	require.NoError(t, err)
+
+	assert.Len(t, list.ID, 16)
+	assert.Equal(t, "Example", list.Issuer)
+	assert.Equal(t, "foobar@example.com", list.UserId)
+	assert.Equal(t, "hotp", list.Type)
+	require.Len(t, list.Entries, 5)
+	assert.Equal(t, Entry{Counter: 3, Code: "969429"}, list.Entries[0])
+	assert.Equal(t, Entry{Counter: 7, Code: "162583"}, list.Entries[4])
+}
+
+func TestNewTotpList(t *testing.T) {
+	key := newKey(t, "totp")
+	from := now.Add(10 * time.Second)
+
+	list, err := NewTotpList(key, from, from.Add(time.Minute), now)
+	require.NoError(t, err)
+
+	// 12:00:00, 12:00:30 and 12:01:00 overlap the range
+	require.Len(t, list.Entries, 3)
+	assert.Equal(t, now, list.Entries[0].ValidFrom)
+	assert.Equal(t, now.Add(30*time.Second), list.Entries[0].ValidUntil)
+	assert.Equal(t, uint64(now.Unix()/30), list.Entries[0].Counter)
+
+	for _, entry := range list.Entries {
+		valid, err := totp.Validate(entry.Code, key.Secret(), entry.ValidFrom.Add(15*time.Second), &totp.TotpOptions{})
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestNewTotpListShortRanges(t *testing.T) {
+	key := newKey(t, "totp")
+
+	for _, from := range []time.Time{now, now.Add(29 * time.Second), time.Unix(0, 0)} {
+		list, err := NewTotpList(key, from, from.Add(500*time.Millisecond), now)
+		require.NoError(t, err, from)
+		require.Len(t, list.Entries, 1, from)
+		assert.Equal(t, uint64(from.Unix()/30), list.Entries[0].Counter, from)
+	}
+
+	// the step starting half a second before to overlaps the range
+	list, err := NewTotpList(key, now, now.Add(30*time.Second+500*time.Millisecond), now)
+	require.NoError(t, err)
+	assert.Len(t, list.Entries, 2)
+}
+
+func TestNewListErrors(t *testing.T) {
+	hotpKey := newKey(t, "hotp")
+	totpKey := newKey(t, "totp")
+
+	_, err := NewHotpList(nil, 0, 5, now)
+	assert.ErrorIs(t, err, common.ErrorNilOtpKey)
+
+	_, err = NewHotpList(totpKey, 0, 5, now)
+	assert.ErrorIs(t, err, common.ErrorUnsupportedKeyType)
+
+	_, err = NewTotpList(hotpKey, now, now.Add(time.Hour), now)
+	assert.ErrorIs(t, err, common.ErrorUnsupportedKeyType)
+
+	for _, count := range []int{0, -1, MaxEntries + 1} {
+		_, err = NewHotpList(hotpKey, 0, count, now)
+		assert.ErrorIs(t, err, common.ErrorInvalidCodeList, count)
+	}
+
+	_, err = NewHotpList(hotpKey, ^uint64(0), 2, now)
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeList)
+
+	_, err = NewTotpList(totpKey, now, now, now)
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeList)
+
+	_, err = NewTotpList(totpKey, now, now.Add(30*MaxEntries*time.Second+time.Second), now)
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeList)
+
+	_, err = NewTotpList(totpKey, time.Unix(-60, 0), now, now)
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeList)
+}
+
+func TestMarker(t *testing.T) {
+	list, err := NewHotpList(newKey(t, "hotp"), 10, 5, now)
+	require.NoError(t, err)
+
+	marker := list.Marker()
+	assert.Equal(t, list.ID, marker.ListID)
+	assert.Equal(t, uint64(10), marker.First)
+	assert.Equal(t, uint64(14), marker.Last)
+
+	assert.Equal(t, 5, marker.Remaining(0))
+	assert.Equal(t, 5, marker.Remaining(10))
+	assert.Equal(t, 2, marker.Used(12))
+	assert.Equal(t, 3, marker.Remaining(12))
+	assert.False(t, marker.Exhausted(14))
+	assert.True(t, marker.Exhausted(15))
+	assert.Equal(t, 5, marker.Used(100))
+
+	data, err := json.Marshal(marker)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), list.Entries[0].Code)
+
+	var decoded Marker
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, marker.ListID, decoded.ListID)
+	assert.Equal(t, marker.Last, decoded.Last)
+}
@@ -0,0 +1,94 @@
+package codelist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the pages are A4 in points, printed with the standard Courier font so no
+// font needs to be embedded.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 56
+	pdfFontSize     = 10
+	pdfLeading      = 14
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// writePDF writes a minimal PDF 1.4 document with the lines split in pages.
+func writePDF(w io.Writer, lines []string) error {
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// objects 1 and 2 are the catalog and the page tree, 3 is the font and
+	// every page takes two more, the page and its content stream
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"}
+	kids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		pageId := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageId))
+
+		content := pdfContent(page)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageId+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buff bytes.Buffer
+	buff.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buff.Len()
+		fmt.Fprintf(&buff, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buff.Len()
+	fmt.Fprintf(&buff, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buff, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buff, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buff.Bytes())
+
+	return err
+}
+
+func pdfContent(lines []string) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&result, "(%s) Tj T*\n", pdfEscape(line))
+	}
+	result.WriteString("ET")
+
+	return result.String()
+}
+
+func pdfEscape(value string) string {
+	var result strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			result.WriteByte('\\')
+			result.WriteRune(r)
+		case r < ' ' || r > '~':
+			result.WriteByte('?')
+		default:
+			result.WriteRune(r)
+		}
+	}
+
+	return result.String()
+}
@@ -0,0 +1,118 @@
+package codelist
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "2006-01-02 15:04:05"
+)
+
+type row struct {
+	Label string
+	Code  string
+}
+
+var htmlTemplate = template.Must(template.New("codelist").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: monospace; }
+td { padding: 2px 16px 2px 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Header}}<p>{{.}}</p>
+{{end}}<table>
+{{range .Rows}}<tr><td>{{.Label}}</td><td>{{.Code}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Text renders the list as plain text.
+func (l *List) Text() string {
+	var result strings.Builder
+	_ = l.WriteText(&result)
+
+	return result.String()
+}
+
+func (l *List) WriteText(w io.Writer) error {
+	_, err := io.WriteString(w, strings.Join(l.lines(), "\n")+"\n")
+	return err
+}
+
+// WriteHTML renders the list as a standalone HTML page.
+func (l *List) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, struct {
+		Title  string
+		Header []string
+		Rows   []row
+	}{
+		Title:  l.title(),
+		Header: l.header(),
+		Rows:   l.rows(),
+	})
+}
+
+// WritePDF renders the list as a PDF document, characters outside of ASCII
+// are printed as question marks.
+func (l *List) WritePDF(w io.Writer) error {
+	return writePDF(w, l.lines())
+}
+
+func (l *List) title() string {
+	return fmt.Sprintf("%s - %s", l.Issuer, l.UserId)
+}
+
+func (l *List) header() []string {
+	result := []string{
+		fmt.Sprintf("One-time codes (%s), list %s, generated %s", strings.ToUpper(l.Type), l.ID, l.CreatedAt.UTC().Format(dateLayout)),
+	}
+
+	if l.Type == "hotp" {
+		return append(result, "Use the codes in order, each code works once.")
+	}
+
+	return append(result, "Times are UTC, each code only works from its start time until its end time.")
+}
+
+func (l *List) rows() []row {
+	result := make([]row, 0, len(l.Entries))
+	for i, entry := range l.Entries {
+		label := fmt.Sprintf("%d", i+1)
+		if !entry.ValidFrom.IsZero() {
+			label = fmt.Sprintf("%s - %s", entry.ValidFrom.UTC().Format(timeLayout), entry.ValidUntil.UTC().Format("15:04:05"))
+		}
+
+		result = append(result, row{Label: label, Code: otp.DisplayCode(entry.Code)})
+	}
+
+	return result
+}
+
+func (l *List) lines() []string {
+	rows := l.rows()
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row.Label))
+	}
+
+	result := append([]string{l.title()}, l.header()...)
+	result = append(result, "")
+	for _, row := range rows {
+		result = append(result, fmt.Sprintf("%*s  %s", width, row.Label, row.Code))
+	}
+
+	return result
+}
@@ -0,0 +1,76 @@
+package codelist
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestText(t *testing.T) {
+	list, err := NewHotpList(newKey(t, "hotp"), 0, 12, now)
+	require.NoError(t, err)
+
+	text := list.Text()
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	assert.Equal(t, "Example - foobar@example.com", lines[0])
+	assert.Contains(t, lines[1], "(HOTP), list "+list.ID+", generated 2024-05-01")
+	assert.Equal(t, " 1  755 224", lines[4])
+	assert.Equal(t, "12  "+otp.DisplayCode(list.Entries[11].Code), lines[15])
+
+	totpList, err := NewTotpList(newKey(t, "totp"), now, now.Add(time.Minute), now)
+	require.NoError(t, err)
+	assert.Contains(t, totpList.Text(), "2024-05-01 12:00:30 - 12:01:00  "+otp.DisplayCode(totpList.Entries[1].Code))
+}
+
+func TestWriteHTML(t *testing.T) {
+	key := newKey(t, "hotp")
+	list, err := NewHotpList(key, 0, 3, now)
+	require.NoError(t, err)
+	list.Issuer = "<script>"
+
+	var buffer bytes.Buffer
+	require.NoError(t, list.WriteHTML(&buffer))
+
+	html := buffer.String()
+	assert.Contains(t, html, "<td>755 224</td>")
+	assert.Contains(t, html, "&lt;script&gt; - foobar@example.com")
+	assert.NotContains(t, html, "<script>")
+}
+
+func TestWritePDF(t *testing.T) {
+	list, err := NewHotpList(newKey(t, "hotp"), 0, 120, now)
+	require.NoError(t, err)
+	list.UserId = "foo(bar)\\é"
+
+	var buffer bytes.Buffer
+	require.NoError(t, list.WritePDF(&buffer))
+	pdf := buffer.String()
+
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	assert.Contains(t, pdf, "(Example - foo\\(bar\\)\\\\?) Tj")
+	assert.Contains(t, pdf, "/Count 3")
+	assert.Contains(t, pdf, "755 224")
+
+	// the cross reference table points at every object
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	require.Len(t, match, 2)
+	xref, err := strconv.Atoi(match[1])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(pdf[xref:], "xref\n"))
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	require.Len(t, offsets, 9)
+	for i, offset := range offsets {
+		position, err := strconv.Atoi(offset[1])
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(pdf[position:], strconv.Itoa(i+1)+" 0 obj\n"), i)
+	}
+}
@@ -0,0 +1,143 @@
+package common
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+type Algorithm uint
+
+const (
+	SHA1Algorithm Algorithm = iota
+	SHA256Algorithm
+	SHA512Algorithm
+	SHA3_256Algorithm
+	SHA3_512Algorithm
+)
+
+type algorithmEntry struct {
+	name     string
+	hashFunc func() hash.Hash
+}
+
+var (
+	algorithmsLock sync.RWMutex
+	algorithms     = map[Algorithm]algorithmEntry{}
+	algorithmNames = map[string]Algorithm{}
+	nextAlgorithm  = SHA3_512Algorithm + 1
+)
+
+func init() {
+	registerAlgorithm(SHA1Algorithm, "SHA1", sha1.New)
+	registerAlgorithm(SHA256Algorithm, "SHA256", sha256.New)
+	registerAlgorithm(SHA512Algorithm, "SHA512", sha512.New)
+	registerAlgorithm(SHA3_256Algorithm, "SHA3-256", sha3.New256)
+	registerAlgorithm(SHA3_512Algorithm, "SHA3-512", sha3.New512)
+}
+
+// RegisterAlgorithm adds a named hash constructor to the registry and returns
+// the Algorithm value that identifies it, the name is what ends up in the
+// otpauth algorithm parameter.
+func RegisterAlgorithm(name string, hashFunc func() hash.Hash) (Algorithm, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || hashFunc == nil {
+		return 0, ErrorInvalidAlgorithm
+	}
+
+	algorithmsLock.Lock()
+	defer algorithmsLock.Unlock()
+
+	if _, ok := algorithmNames[normalizeAlgorithmName(name)]; ok {
+		return 0, ErrorDuplicateAlgorithm
+	}
+
+	algorithm := nextAlgorithm
+	nextAlgorithm++
+	registerAlgorithm(algorithm, name, hashFunc)
+
+	return algorithm, nil
+}
+
+func ParseAlgorithm(name string) (Algorithm, error) {
+	algorithmsLock.RLock()
+	defer algorithmsLock.RUnlock()
+
+	algorithm, ok := algorithmNames[normalizeAlgorithmName(name)]
+	if !ok {
+		return 0, ErrorUnknownAlgorithm
+	}
+
+	return algorithm, nil
+}
+
+func (a Algorithm) HashFunc() (func() hash.Hash, error) {
+	algorithmsLock.RLock()
+	defer algorithmsLock.RUnlock()
+
+	entry, ok := algorithms[a]
+	if !ok {
+		return nil, ErrorUnknownAlgorithm
+	}
+
+	return entry.hashFunc, nil
+}
+
+// Hash returns a new hash of the algorithm, an unknown algorithm falls back
+// to SHA-1 as it always did, use NewHash to get an error instead.
+func (a Algorithm) Hash() hash.Hash {
+	result, err := a.NewHash()
+	if err != nil {
+		return sha1.New()
+	}
+
+	return result
+}
+
+// NewHash returns a new hash of the algorithm or ErrorUnknownAlgorithm.
+func (a Algorithm) NewHash() (hash.Hash, error) {
+	hashFunc, err := a.HashFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	return hashFunc(), nil
+}
+
+func (a Algorithm) Validate() error {
+	_, err := a.HashFunc()
+	return err
+}
+
+func (a Algorithm) String() string {
+	algorithmsLock.RLock()
+	defer algorithmsLock.RUnlock()
+
+	entry, ok := algorithms[a]
+	if !ok {
+		return fmt.Sprintf("Algorithm(%d)", uint(a))
+	}
+
+	return entry.name
+}
+
+func registerAlgorithm(algorithm Algorithm, name string, hashFunc func() hash.Hash) {
+	algorithms[algorithm] = algorithmEntry{
+		name:     name,
+		hashFunc: hashFunc,
+	}
+	algorithmNames[normalizeAlgorithmName(name)] = algorithm
+}
+
+// normalizeAlgorithmName makes lookups tolerant to the different spellings
+// used by authenticator apps, e.g. "sha-256", "SHA256" and "sha_256".
+func normalizeAlgorithmName(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	return strings.NewReplacer("-", "", "_", "").Replace(name)
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Algorithm
+		wantErr error
+	}{
+		{"sha1", "SHA1", SHA1Algorithm, nil},
+		{"lower case sha256", "sha256", SHA256Algorithm, nil},
+		{"dashed sha512", "SHA-512", SHA512Algorithm, nil},
+		{"sha3 256", "SHA3-256", SHA3_256Algorithm, nil},
+		{"sha3 512 lower case", "sha3-512", SHA3_512Algorithm, nil},
+		{"unknown", "WHIRLPOOL", 0, ErrorUnknownAlgorithm},
+		{"empty", "", 0, ErrorUnknownAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAlgorithm(tt.value)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAlgorithmStringRoundTrip(t *testing.T) {
+	for _, algorithm := range []Algorithm{SHA1Algorithm, SHA256Algorithm, SHA512Algorithm, SHA3_256Algorithm, SHA3_512Algorithm} {
+		parsed, err := ParseAlgorithm(algorithm.String())
+		require.NoError(t, err)
+		assert.Equal(t, algorithm, parsed)
+	}
+}
+
+func TestAlgorithmHash(t *testing.T) {
+	h, err := SHA3_256Algorithm.NewHash()
+	require.NoError(t, err)
+	assert.Equal(t, 32, h.Size())
+
+	h, err = SHA512Algorithm.NewHash()
+	require.NoError(t, err)
+	assert.Equal(t, 64, h.Size())
+
+	assert.Equal(t, 32, SHA256Algorithm.Hash().Size())
+	assert.Equal(t, 32, SHA3_256Algorithm.Hash().Size())
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	unknown := Algorithm(9999)
+
+	h, err := unknown.NewHash()
+	assert.Nil(t, h)
+	assert.ErrorIs(t, err, ErrorUnknownAlgorithm)
+	assert.Equal(t, sha1.Size, unknown.Hash().Size())
+	assert.Equal(t, ErrorUnknownAlgorithm, unknown.Validate())
+	assert.Equal(t, "Algorithm(9999)", unknown.String())
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	algorithm, err := RegisterAlgorithm("MD5", md5.New)
+	require.NoError(t, err)
+	assert.Equal(t, "MD5", algorithm.String())
+
+	parsed, err := ParseAlgorithm("md5")
+	require.NoError(t, err)
+	assert.Equal(t, algorithm, parsed)
+
+	h, err := algorithm.NewHash()
+	require.NoError(t, err)
+	assert.Equal(t, md5.Size, h.Size())
+
+	_, err = RegisterAlgorithm("md5", md5.New)
+	assert.ErrorIs(t, err, ErrorDuplicateAlgorithm)
+
+	_, err = RegisterAlgorithm("SHA-1", md5.New)
+	assert.ErrorIs(t, err, ErrorDuplicateAlgorithm)
+
+	_, err = RegisterAlgorithm("", md5.New)
+	assert.ErrorIs(t, err, ErrorInvalidAlgorithm)
+
+	_, err = RegisterAlgorithm("NOHASH", nil)
+	assert.ErrorIs(t, err, ErrorInvalidAlgorithm)
+}
@@ -1,38 +1,65 @@
 package common
 
 import (
-	"crypto"
-	_ "crypto/sha1"
-	_ "crypto/sha256"
-	_ "crypto/sha512"
-	"errors"
 	"fmt"
-	"hash"
+	"strconv"
 )
 
 const MODULUS_SIZE = 8
 const DEFAULT_IMAGE_SIZE = 512
 
-//lint:ignore ST1005 the error code is not going to be used in conjunction with others
-var ErrorWrongCodeSize = errors.New("Code length is not of expected length")
-
-//lint:ignore ST1005 the error code is not going to be used in conjunction with others
-var ErrorEmptyIssuer = errors.New("Issuer cannot be empty")
-
-var ErrorEmptyUserID = errors.New("UserID cannot be empty")
-
-var ErrorNilOtpKeyOptions = errors.New("OtpKeyOptions cannot be nil")
-
-var ErrorInvalidSecret = errors.New("invalid base32 encoding of the secret")
-
 type PassCodeSize uint
 
 const (
+	FourDigits  PassCodeSize = 4
+	FiveDigits  PassCodeSize = 5
 	SixDigits   PassCodeSize = 6
 	SevenDigits PassCodeSize = 7
 	EightDigits PassCodeSize = 8
+	NineDigits  PassCodeSize = 9
+	TenDigits   PassCodeSize = 10
+)
+
+const (
+	MinCodeSize = FourDigits
+	MaxCodeSize = TenDigits
 )
 
+var codeSizeModulus = [...]uint64{
+	1, 10, 100, 1000, 10000, 100000, 1000000, 10000000, 100000000, 1000000000, 10000000000,
+}
+
+func NewPassCodeSize(digits int) (PassCodeSize, error) {
+	if digits < int(MinCodeSize) || digits > int(MaxCodeSize) {
+		return 0, ErrorInvalidCodeSize
+	}
+
+	return PassCodeSize(digits), nil
+}
+
+func ParsePassCodeSize(value string) (PassCodeSize, error) {
+	digits, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, ErrorInvalidCodeSize.WithCause(err)
+	}
+
+	return NewPassCodeSize(digits)
+}
+
+func (d PassCodeSize) Validate() error {
+	if d < MinCodeSize || d > MaxCodeSize {
+		return ErrorInvalidCodeSize
+	}
+
+	return nil
+}
+
+// Modulus returns 10^d, the value the truncated HMAC is reduced by, it
+// expects the size to have been validated beforehand.
+func (d PassCodeSize) Modulus() uint64 {
+	return codeSizeModulus[d]
+}
+
 func (d PassCodeSize) Length() int {
 	return int(d)
 }
@@ -42,36 +69,27 @@ func (d PassCodeSize) String() string {
 }
 
 func (d PassCodeSize) Format(value uint64) string {
-	f := fmt.Sprintf("%%0%dd", d)
-	return fmt.Sprintf(f, value)
+	var buff [20]byte
+	return string(d.AppendFormat(buff[:0], value))
 }
 
-type Algorithm uint
-
-const (
-	SHA1Algorithm Algorithm = iota
-	SHA256Algorithm
-	SHA512Algorithm
-)
-
-func (a Algorithm) Hash() hash.Hash {
-	switch a {
-	case SHA256Algorithm:
-		return crypto.SHA256.New()
-	case SHA512Algorithm:
-		return crypto.SHA512.New()
-	default:
-		return crypto.SHA1.New()
+// AppendFormat appends the value left padded with zeros to d digits, unlike
+// Format it does not allocate when dst has enough capacity.
+func (d PassCodeSize) AppendFormat(dst []byte, value uint64) []byte {
+	var digits [20]byte
+	i := len(digits)
+	for {
+		i--
+		digits[i] = byte('0' + value%10)
+		value /= 10
+		if value == 0 {
+			break
+		}
 	}
-}
 
-func (a Algorithm) String() string {
-	switch a {
-	case SHA256Algorithm:
-		return "SHA256"
-	case SHA512Algorithm:
-		return "SHA512"
-	default:
-		return "SHA1"
+	for n := len(digits) - i; n < int(d); n++ {
+		dst = append(dst, '0')
 	}
+
+	return append(dst, digits[i:]...)
 }
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPassCodeSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		digits  int
+		want    PassCodeSize
+		wantErr error
+	}{
+		{"four digits", 4, FourDigits, nil},
+		{"six digits", 6, SixDigits, nil},
+		{"ten digits", 10, TenDigits, nil},
+		{"too short", 3, 0, ErrorInvalidCodeSize},
+		{"too long", 11, 0, ErrorInvalidCodeSize},
+		{"negative", -6, 0, ErrorInvalidCodeSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPassCodeSize(tt.digits)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePassCodeSize(t *testing.T) {
+	got, err := ParsePassCodeSize("9")
+	assert.NoError(t, err)
+	assert.Equal(t, NineDigits, got)
+
+	_, err = ParsePassCodeSize("six")
+	assert.ErrorIs(t, err, ErrorInvalidCodeSize)
+
+	_, err = ParsePassCodeSize("12")
+	assert.ErrorIs(t, err, ErrorInvalidCodeSize)
+}
+
+func TestPassCodeSizeFormat(t *testing.T) {
+	assert.Equal(t, "0042", FourDigits.Format(42))
+	assert.Equal(t, "0000000042", TenDigits.Format(42))
+	assert.Equal(t, "000000", SixDigits.Format(0))
+	assert.Equal(t, "1234567", SixDigits.Format(1234567))
+	assert.Equal(t, "code 0042", string(FourDigits.AppendFormat([]byte("code "), 42)))
+	assert.Equal(t, uint64(10000000000), TenDigits.Modulus())
+	assert.NoError(t, FiveDigits.Validate())
+	assert.Equal(t, ErrorInvalidCodeSize, PassCodeSize(0).Validate())
+}
@@ -0,0 +1,126 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// DecodeJSONStrict decodes data into v rejecting unknown fields, errors
+// from encoding/json are reported as ErrorInvalidEncoding while our own
+// validation errors are returned as they are.
+func DecodeJSONStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		var otpErr *OtpError
+		if errors.As(err, &otpErr) {
+			return err
+		}
+
+		return ErrorInvalidEncoding.WithCause(err)
+	}
+
+	return nil
+}
+
+func (a Algorithm) MarshalText() ([]byte, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	return []byte(a.String()), nil
+}
+
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	algorithm, err := ParseAlgorithm(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = algorithm
+
+	return nil
+}
+
+// MarshalBinary encodes the algorithm by name, the numeric value of the
+// registered algorithms is not stable across processes.
+func (a Algorithm) MarshalBinary() ([]byte, error) {
+	return a.MarshalText()
+}
+
+func (a *Algorithm) UnmarshalBinary(data []byte) error {
+	return a.UnmarshalText(data)
+}
+
+func (d PassCodeSize) MarshalJSON() ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(uint(d))
+}
+
+// UnmarshalJSON only accepts a JSON integer within the supported range.
+func (d *PassCodeSize) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var digits int
+	if err := json.Unmarshal(data, &digits); err != nil {
+		return ErrorInvalidCodeSize.WithCause(err)
+	}
+
+	codeSize, err := NewPassCodeSize(digits)
+	if err != nil {
+		return err
+	}
+
+	*d = codeSize
+
+	return nil
+}
+
+func (d PassCodeSize) MarshalText() ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return []byte(d.String()), nil
+}
+
+func (d *PassCodeSize) UnmarshalText(text []byte) error {
+	codeSize, err := ParsePassCodeSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = codeSize
+
+	return nil
+}
+
+func (d PassCodeSize) MarshalBinary() ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return []byte{byte(d)}, nil
+}
+
+func (d *PassCodeSize) UnmarshalBinary(data []byte) error {
+	if len(data) != 1 {
+		return ErrorInvalidEncoding.WithField("PassCodeSize")
+	}
+
+	codeSize, err := NewPassCodeSize(int(data[0]))
+	if err != nil {
+		return err
+	}
+
+	*d = codeSize
+
+	return nil
+}
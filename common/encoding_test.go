@@ -0,0 +1,82 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlgorithmJSON(t *testing.T) {
+	data, err := json.Marshal(SHA256Algorithm)
+	require.NoError(t, err)
+	assert.Equal(t, `"SHA256"`, string(data))
+
+	data, err = json.Marshal(SHA3_512Algorithm)
+	require.NoError(t, err)
+	assert.Equal(t, `"SHA3-512"`, string(data))
+
+	var algorithm Algorithm
+	require.NoError(t, json.Unmarshal([]byte(`"sha512"`), &algorithm))
+	assert.Equal(t, SHA512Algorithm, algorithm)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`"MD4"`), &algorithm), ErrorUnknownAlgorithm)
+	assert.Error(t, json.Unmarshal([]byte(`1`), &algorithm))
+
+	_, err = json.Marshal(Algorithm(9999))
+	assert.ErrorIs(t, err, ErrorUnknownAlgorithm)
+}
+
+func TestAlgorithmBinary(t *testing.T) {
+	data, err := SHA3_256Algorithm.MarshalBinary()
+	require.NoError(t, err)
+
+	var algorithm Algorithm
+	require.NoError(t, algorithm.UnmarshalBinary(data))
+	assert.Equal(t, SHA3_256Algorithm, algorithm)
+}
+
+func TestPassCodeSizeJSON(t *testing.T) {
+	data, err := json.Marshal(SixDigits)
+	require.NoError(t, err)
+	assert.Equal(t, `6`, string(data))
+
+	var codeSize PassCodeSize
+	require.NoError(t, json.Unmarshal([]byte(`8`), &codeSize))
+	assert.Equal(t, EightDigits, codeSize)
+
+	for _, invalid := range []string{`"6"`, `6.5`, `11`, `-1`, `null`, `true`} {
+		codeSize = 0
+		err := json.Unmarshal([]byte(invalid), &codeSize)
+		if invalid == `null` {
+			// null leaves the value untouched as for any other json type
+			assert.NoError(t, err)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrorInvalidCodeSize, invalid)
+	}
+
+	_, err = json.Marshal(PassCodeSize(2))
+	assert.ErrorIs(t, err, ErrorInvalidCodeSize)
+}
+
+func TestPassCodeSizeTextAndBinary(t *testing.T) {
+	text, err := NineDigits.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "9", string(text))
+
+	var codeSize PassCodeSize
+	require.NoError(t, codeSize.UnmarshalText([]byte("7")))
+	assert.Equal(t, SevenDigits, codeSize)
+
+	data, err := TenDigits.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{10}, data)
+
+	require.NoError(t, codeSize.UnmarshalBinary(data))
+	assert.Equal(t, TenDigits, codeSize)
+
+	assert.ErrorIs(t, codeSize.UnmarshalBinary([]byte{}), ErrorInvalidEncoding)
+	assert.ErrorIs(t, codeSize.UnmarshalBinary([]byte{20}), ErrorInvalidCodeSize)
+}
@@ -0,0 +1,248 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type ErrorCode uint
+
+const (
+	UnknownErrorCode ErrorCode = iota
+	WrongCodeSizeErrorCode
+	InvalidCodeSizeErrorCode
+	EmptyIssuerErrorCode
+	EmptyUserIDErrorCode
+	NilOptionsErrorCode
+	NilOtpKeyErrorCode
+	InvalidSecretErrorCode
+	UnknownAlgorithmErrorCode
+	InvalidAlgorithmErrorCode
+	DuplicateAlgorithmErrorCode
+	TimeBeforeEpochErrorCode
+	UnsupportedKeyTypeErrorCode
+	NilCredentialErrorCode
+	NilUserCredentialsErrorCode
+	CredentialNotFoundErrorCode
+	DuplicateCredentialErrorCode
+	CredentialDisabledErrorCode
+	LockedOutErrorCode
+	AuditTamperedErrorCode
+	InvalidKeyErrorCode
+	InvalidEncodingErrorCode
+	InvalidConfigurationErrorCode
+	PolicyViolationErrorCode
+	InvalidPassphraseErrorCode
+	AccountNotFoundErrorCode
+	EmptyRecipientErrorCode
+	EmptyPurposeErrorCode
+	ResendCooldownErrorCode
+	QuotaExceededErrorCode
+	ChallengeNotFoundErrorCode
+	ChallengeExpiredErrorCode
+	TooManyAttemptsErrorCode
+	InvalidTransactionErrorCode
+	InvalidCodeListErrorCode
+)
+
+func (c ErrorCode) String() string {
+	switch c {
+	case WrongCodeSizeErrorCode:
+		return "WRONG_CODE_SIZE"
+	case InvalidCodeSizeErrorCode:
+		return "INVALID_CODE_SIZE"
+	case EmptyIssuerErrorCode:
+		return "EMPTY_ISSUER"
+	case EmptyUserIDErrorCode:
+		return "EMPTY_USER_ID"
+	case NilOptionsErrorCode:
+		return "NIL_OPTIONS"
+	case NilOtpKeyErrorCode:
+		return "NIL_OTP_KEY"
+	case InvalidSecretErrorCode:
+		return "INVALID_SECRET"
+	case UnknownAlgorithmErrorCode:
+		return "UNKNOWN_ALGORITHM"
+	case InvalidAlgorithmErrorCode:
+		return "INVALID_ALGORITHM"
+	case DuplicateAlgorithmErrorCode:
+		return "DUPLICATE_ALGORITHM"
+	case TimeBeforeEpochErrorCode:
+		return "TIME_BEFORE_EPOCH"
+	case UnsupportedKeyTypeErrorCode:
+		return "UNSUPPORTED_KEY_TYPE"
+	case NilCredentialErrorCode:
+		return "NIL_CREDENTIAL"
+	case NilUserCredentialsErrorCode:
+		return "NIL_USER_CREDENTIALS"
+	case CredentialNotFoundErrorCode:
+		return "CREDENTIAL_NOT_FOUND"
+	case DuplicateCredentialErrorCode:
+		return "DUPLICATE_CREDENTIAL"
+	case CredentialDisabledErrorCode:
+		return "CREDENTIAL_DISABLED"
+	case LockedOutErrorCode:
+		return "LOCKED_OUT"
+	case AuditTamperedErrorCode:
+		return "AUDIT_TAMPERED"
+	case InvalidKeyErrorCode:
+		return "INVALID_KEY"
+	case InvalidEncodingErrorCode:
+		return "INVALID_ENCODING"
+	case InvalidConfigurationErrorCode:
+		return "INVALID_CONFIGURATION"
+	case PolicyViolationErrorCode:
+		return "POLICY_VIOLATION"
+	case InvalidPassphraseErrorCode:
+		return "INVALID_PASSPHRASE"
+	case AccountNotFoundErrorCode:
+		return "ACCOUNT_NOT_FOUND"
+	case EmptyRecipientErrorCode:
+		return "EMPTY_RECIPIENT"
+	case EmptyPurposeErrorCode:
+		return "EMPTY_PURPOSE"
+	case ResendCooldownErrorCode:
+		return "RESEND_COOLDOWN"
+	case QuotaExceededErrorCode:
+		return "QUOTA_EXCEEDED"
+	case ChallengeNotFoundErrorCode:
+		return "CHALLENGE_NOT_FOUND"
+	case ChallengeExpiredErrorCode:
+		return "CHALLENGE_EXPIRED"
+	case TooManyAttemptsErrorCode:
+		return "TOO_MANY_ATTEMPTS"
+	case InvalidTransactionErrorCode:
+		return "INVALID_TRANSACTION"
+	case InvalidCodeListErrorCode:
+		return "INVALID_CODE_LIST"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// OtpError is the error returned by every package in this module, errors.Is
+// matches it against the Error variables below by code, so the field and
+// cause attached to it do not get in the way.
+type OtpError struct {
+	Code    ErrorCode
+	Field   string
+	Message string
+	Err     error
+}
+
+func NewOtpError(code ErrorCode, message string) *OtpError {
+	result := OtpError{
+		Code:    code,
+		Message: message,
+	}
+
+	return &result
+}
+
+func (e *OtpError) Error() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{e.Field, e.Message} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	if e.Err != nil {
+		parts = append(parts, e.Err.Error())
+	}
+
+	return strings.Join(parts, ": ")
+}
+
+func (e *OtpError) Unwrap() error {
+	return e.Err
+}
+
+func (e *OtpError) Is(target error) bool {
+	t, ok := target.(*OtpError)
+	if !ok {
+		return false
+	}
+
+	return e.Code == t.Code
+}
+
+// WithField returns a copy of the error naming the parameter that was invalid.
+func (e *OtpError) WithField(field string) *OtpError {
+	result := *e
+	result.Field = field
+
+	return &result
+}
+
+// WithCause returns a copy of the error wrapping the underlying cause.
+func (e *OtpError) WithCause(err error) *OtpError {
+	result := *e
+	result.Err = err
+
+	return &result
+}
+
+// WithField sets the field on an error returned by another package call. An
+// error wrapping an OtpError is wrapped in one with the same code, so the
+// whole chain is kept, and any other error with the unknown error code.
+func WithField(err error, field string) error {
+	if err == nil {
+		return nil
+	}
+
+	if otpError, ok := err.(*OtpError); ok {
+		return otpError.WithField(field)
+	}
+
+	var otpError *OtpError
+	if errors.As(err, &otpError) {
+		result := OtpError{
+			Code:  otpError.Code,
+			Field: field,
+			Err:   err,
+		}
+
+		return &result
+	}
+
+	return NewOtpError(UnknownErrorCode, "unexpected error").WithCause(err).WithField(field)
+}
+
+var (
+	ErrorWrongCodeSize        = NewOtpError(WrongCodeSizeErrorCode, "Code length is not of expected length")
+	ErrorInvalidCodeSize      = NewOtpError(InvalidCodeSizeErrorCode, fmt.Sprintf("code size must be between %d and %d digits", MinCodeSize, MaxCodeSize))
+	ErrorEmptyIssuer          = NewOtpError(EmptyIssuerErrorCode, "Issuer cannot be empty")
+	ErrorEmptyUserID          = NewOtpError(EmptyUserIDErrorCode, "UserID cannot be empty")
+	ErrorNilOtpKeyOptions     = NewOtpError(NilOptionsErrorCode, "OtpKeyOptions cannot be nil")
+	ErrorNilOtpKey            = NewOtpError(NilOtpKeyErrorCode, "OtpKey cannot be nil")
+	ErrorInvalidSecret        = NewOtpError(InvalidSecretErrorCode, "invalid base32 encoding of the secret")
+	ErrorUnknownAlgorithm     = NewOtpError(UnknownAlgorithmErrorCode, "unknown hash algorithm")
+	ErrorInvalidAlgorithm     = NewOtpError(InvalidAlgorithmErrorCode, "algorithm name and hash function cannot be empty")
+	ErrorDuplicateAlgorithm   = NewOtpError(DuplicateAlgorithmErrorCode, "algorithm is already registered")
+	ErrorTimeBeforeEpoch      = NewOtpError(TimeBeforeEpochErrorCode, "time cannot be before the TOTP epoch (T0)")
+	ErrorUnsupportedKeyType   = NewOtpError(UnsupportedKeyTypeErrorCode, "key type must be either totp or hotp")
+	ErrorNilCredential        = NewOtpError(NilCredentialErrorCode, "Credential cannot be nil")
+	ErrorNilUserCredentials   = NewOtpError(NilUserCredentialsErrorCode, "UserCredentials cannot be nil")
+	ErrorCredentialNotFound   = NewOtpError(CredentialNotFoundErrorCode, "credential not found")
+	ErrorDuplicateCredential  = NewOtpError(DuplicateCredentialErrorCode, "a credential with the same id already exists")
+	ErrorCredentialDisabled   = NewOtpError(CredentialDisabledErrorCode, "credential is disabled")
+	ErrorLockedOut            = NewOtpError(LockedOutErrorCode, "too many failed attempts, try again later")
+	ErrorAuditTampered        = NewOtpError(AuditTamperedErrorCode, "audit log hash chain is broken")
+	ErrorInvalidKey           = NewOtpError(InvalidKeyErrorCode, "invalid otpauth key uri")
+	ErrorInvalidEncoding      = NewOtpError(InvalidEncodingErrorCode, "invalid encoded value")
+	ErrorInvalidConfiguration = NewOtpError(InvalidConfigurationErrorCode, "invalid configuration value")
+	ErrorPolicyViolation      = NewOtpError(PolicyViolationErrorCode, "value is not allowed by the policy")
+	ErrorInvalidPassphrase    = NewOtpError(InvalidPassphraseErrorCode, "wrong passphrase or corrupted vault")
+	ErrorAccountNotFound      = NewOtpError(AccountNotFoundErrorCode, "account not found")
+	ErrorEmptyRecipient       = NewOtpError(EmptyRecipientErrorCode, "Recipient cannot be empty")
+	ErrorEmptyPurpose         = NewOtpError(EmptyPurposeErrorCode, "Purpose cannot be empty")
+	ErrorResendCooldown       = NewOtpError(ResendCooldownErrorCode, "a code was sent recently, wait before requesting another one")
+	ErrorQuotaExceeded        = NewOtpError(QuotaExceededErrorCode, "too many codes were sent to the recipient")
+	ErrorChallengeNotFound    = NewOtpError(ChallengeNotFoundErrorCode, "no code was sent for the purpose and recipient")
+	ErrorChallengeExpired     = NewOtpError(ChallengeExpiredErrorCode, "the code has expired")
+	ErrorTooManyAttempts      = NewOtpError(TooManyAttemptsErrorCode, "too many wrong codes, request a new one")
+	ErrorInvalidTransaction   = NewOtpError(InvalidTransactionErrorCode, "the transaction details are invalid")
+	ErrorInvalidCodeList      = NewOtpError(InvalidCodeListErrorCode, "the code list size or range is invalid")
+)
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOtpErrorIs(t *testing.T) {
+	err := ErrorInvalidSecret.WithField("secret").WithCause(errors.New("bad input"))
+
+	assert.ErrorIs(t, err, ErrorInvalidSecret)
+	assert.NotErrorIs(t, err, ErrorWrongCodeSize)
+	assert.Equal(t, "secret: invalid base32 encoding of the secret: bad input", err.Error())
+	assert.Equal(t, "", ErrorInvalidSecret.Field)
+	assert.Nil(t, ErrorInvalidSecret.Err)
+}
+
+func TestOtpErrorAs(t *testing.T) {
+	_, err := ParsePassCodeSize("six")
+
+	var otpError *OtpError
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, InvalidCodeSizeErrorCode, otpError.Code)
+	assert.Equal(t, "INVALID_CODE_SIZE", otpError.Code.String())
+
+	var numError *strconv.NumError
+	assert.ErrorAs(t, err, &numError)
+}
+
+func TestWithField(t *testing.T) {
+	assert.Nil(t, WithField(nil, "foo"))
+
+	err := WithField(ErrorUnknownAlgorithm, "Algorithm")
+	var otpError *OtpError
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, "Algorithm", otpError.Field)
+	assert.ErrorIs(t, err, ErrorUnknownAlgorithm)
+
+	cause := errors.New("boom")
+	err = WithField(cause, "foo")
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, UnknownErrorCode, otpError.Code)
+	assert.Equal(t, "foo", otpError.Field)
+	assert.ErrorIs(t, err, cause)
+
+	// the context wrapped around an OtpError is kept
+	wrapped := fmt.Errorf("parsing options: %w", ErrorUnknownAlgorithm.WithCause(cause))
+	err = WithField(wrapped, "Algorithm")
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, UnknownAlgorithmErrorCode, otpError.Code)
+	assert.Equal(t, "Algorithm", otpError.Field)
+	assert.ErrorIs(t, err, ErrorUnknownAlgorithm)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "Algorithm: parsing options: unknown hash algorithm: boom", err.Error())
+}
+
+func TestErrorCodesAreDistinct(t *testing.T) {
+	seen := map[ErrorCode]bool{}
+	for _, err := range []*OtpError{
+		ErrorWrongCodeSize, ErrorInvalidCodeSize, ErrorEmptyIssuer, ErrorEmptyUserID,
+		ErrorNilOtpKeyOptions, ErrorNilOtpKey, ErrorInvalidSecret, ErrorUnknownAlgorithm,
+		ErrorInvalidAlgorithm, ErrorDuplicateAlgorithm, ErrorTimeBeforeEpoch, ErrorUnsupportedKeyType,
+		ErrorNilCredential, ErrorNilUserCredentials, ErrorCredentialNotFound, ErrorDuplicateCredential,
+		ErrorCredentialDisabled, ErrorLockedOut, ErrorAuditTampered,
+		ErrorInvalidKey, ErrorInvalidEncoding, ErrorInvalidConfiguration,
+		ErrorPolicyViolation, ErrorInvalidPassphrase, ErrorAccountNotFound,
+		ErrorEmptyRecipient, ErrorEmptyPurpose, ErrorResendCooldown, ErrorQuotaExceeded,
+		ErrorChallengeNotFound, ErrorChallengeExpired, ErrorTooManyAttempts,
+		ErrorInvalidTransaction, ErrorInvalidCodeList,
+	} {
+		assert.False(t, seen[err.Code], "duplicated code %v", err.Code)
+		assert.NotEqual(t, "UNKNOWN", err.Code.String())
+		seen[err.Code] = true
+	}
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/credential"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/skip2/go-qrcode"
+)
+
+// Config holds the OTP policy, the json and yaml keys are also used to name
+// the offending value in validation errors, e.g. "totp.period".
+type Config struct {
+	Issuer    string        `json:"issuer" yaml:"issuer"`
+	Digits    int           `json:"digits" yaml:"digits"`
+	Algorithm string        `json:"algorithm" yaml:"algorithm"`
+	Totp      TotpConfig    `json:"totp" yaml:"totp"`
+	Hotp      HotpConfig    `json:"hotp" yaml:"hotp"`
+	Lockout   LockoutConfig `json:"lockout" yaml:"lockout"`
+	Qr        QrConfig      `json:"qr" yaml:"qr"`
+}
+
+type TotpConfig struct {
+	T0         int64 `json:"t0" yaml:"t0"`
+	Period     int   `json:"period" yaml:"period"`
+	Skew       int   `json:"skew" yaml:"skew"`
+	PastSkew   int   `json:"past_skew" yaml:"past_skew"`
+	FutureSkew int   `json:"future_skew" yaml:"future_skew"`
+	MaxDrift   int   `json:"max_drift" yaml:"max_drift"`
+}
+
+type HotpConfig struct {
+	LookAhead int `json:"look_ahead" yaml:"look_ahead"`
+}
+
+type LockoutConfig struct {
+	// MaxFailures is zero by default, which disables the lockout.
+	MaxFailures int `json:"max_failures" yaml:"max_failures"`
+	// Duration is a time.ParseDuration string such as "15m".
+	Duration string `json:"duration" yaml:"duration"`
+}
+
+type QrConfig struct {
+	Size int `json:"size" yaml:"size"`
+	// RecoveryLevel is one of low, medium, high or highest.
+	RecoveryLevel string `json:"recovery_level" yaml:"recovery_level"`
+}
+
+func NewDefaultConfig() *Config {
+	totpOptions := totp.NewDefaultTotpOptions()
+
+	result := Config{
+		Digits:    int(common.SixDigits),
+		Algorithm: common.SHA1Algorithm.String(),
+		Totp: TotpConfig{
+			T0:       totpOptions.T0,
+			Period:   int(totpOptions.Period),
+			Skew:     int(totpOptions.Skew),
+			MaxDrift: totp.DefaultMaxDrift,
+		},
+		Hotp: HotpConfig{
+			LookAhead: hotp.DefaultLookAhead,
+		},
+		Lockout: LockoutConfig{
+			Duration: credential.DefaultLockoutDuration.String(),
+		},
+		Qr: QrConfig{
+			Size:          common.DEFAULT_IMAGE_SIZE,
+			RecoveryLevel: "highest",
+		},
+	}
+
+	return &result
+}
+
+// Validate returns the first invalid value, the error field is the key
+// of the value.
+func (c *Config) Validate() error {
+	for _, s := range settings {
+		if err := s.check(c); err != nil {
+			return invalid(s.key, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) OtpOptions() (*otp.OtpOptions, error) {
+	codeSize, err := common.NewPassCodeSize(c.Digits)
+	if err != nil {
+		return nil, invalid("digits", err)
+	}
+
+	algorithm, err := common.ParseAlgorithm(c.Algorithm)
+	if err != nil {
+		return nil, invalid("algorithm", err)
+	}
+
+	return otp.NewOtpOptions(codeSize, algorithm)
+}
+
+func (c *Config) TotpOptions() (*totp.TotpOptions, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	options, err := c.OtpOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	result := totp.TotpOptions{
+		T0:         c.Totp.T0,
+		Period:     uint(c.Totp.Period),
+		Skew:       uint(c.Totp.Skew),
+		PastSkew:   uint(c.Totp.PastSkew),
+		FutureSkew: uint(c.Totp.FutureSkew),
+		CodeSize:   options.CodeSize,
+		Algorithm:  options.Algorithm,
+	}
+
+	return &result, nil
+}
+
+// KeyOptions returns the options to enroll a new key for the user with the
+// configured issuer, digits and algorithm.
+func (c *Config) KeyOptions(userId string) (*otp.OtpKeyOptions, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	options, err := c.OtpOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	result := otp.NewDefaultOtpKeyOptions(c.Issuer, userId)
+	result.Options = options
+
+	return result, nil
+}
+
+func (c *Config) QrOptions() (*otp.QrOptions, error) {
+	level, err := parseRecoveryLevel(c.Qr.RecoveryLevel)
+	if err != nil {
+		return nil, invalid("qr.recovery_level", err)
+	}
+
+	if c.Qr.Size == 0 {
+		return nil, invalid("qr.size", errZero)
+	}
+
+	result := otp.QrOptions{
+		Size:          c.Qr.Size,
+		RecoveryLevel: level,
+	}
+
+	return &result, nil
+}
+
+// Verifier returns a credential verifier with the configured drift,
+// look ahead and lockout thresholds.
+func (c *Config) Verifier() (*credential.Verifier, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	duration, _ := time.ParseDuration(c.Lockout.Duration)
+
+	result := credential.NewVerifier()
+	result.HotpLookAhead = uint(c.Hotp.LookAhead)
+	result.MaxDrift = uint(c.Totp.MaxDrift)
+	result.MaxFailures = uint(c.Lockout.MaxFailures)
+	result.LockoutDuration = duration
+
+	return result, nil
+}
+
+func parseRecoveryLevel(value string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToLower(value) {
+	case "low":
+		return qrcode.Low, nil
+	case "medium":
+		return qrcode.Medium, nil
+	case "high":
+		return qrcode.High, nil
+	case "highest":
+		return qrcode.Highest, nil
+	default:
+		return 0, fmt.Errorf("unknown recovery level %q", value)
+	}
+}
+
+func invalid(key string, err error) error {
+	return common.ErrorInvalidConfiguration.WithField(key).WithCause(err)
+}
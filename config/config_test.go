@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go/configuration"
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestDefaultConfig(t *testing.T) {
+	config := NewDefaultConfig()
+	require.NoError(t, config.Validate())
+
+	options, err := config.TotpOptions()
+	require.NoError(t, err)
+	assert.Equal(t, uint(30), options.Period)
+	assert.Equal(t, uint(1), options.Skew)
+	assert.Equal(t, common.SixDigits, options.CodeSize)
+	assert.Equal(t, common.SHA1Algorithm, options.Algorithm)
+
+	verifier, err := config.Verifier()
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), verifier.MaxFailures)
+	assert.Equal(t, 15*time.Minute, verifier.LockoutDuration)
+
+	qr, err := config.QrOptions()
+	require.NoError(t, err)
+	assert.Equal(t, common.DEFAULT_IMAGE_SIZE, qr.Size)
+	assert.Equal(t, qrcode.Highest, qr.RecoveryLevel)
+}
+
+func TestLoadYamlFile(t *testing.T) {
+	path := writeFile(t, "otp.yaml", `
+issuer: example
+digits: 8
+algorithm: sha256
+totp:
+  period: 60
+  past_skew: 2
+lockout:
+  max_failures: 3
+  duration: 1h
+qr:
+  recovery_level: medium
+`)
+
+	config := NewDefaultConfig()
+	require.NoError(t, config.LoadFile(path))
+
+	options, err := config.TotpOptions()
+	require.NoError(t, err)
+	assert.Equal(t, uint(60), options.Period)
+	assert.Equal(t, uint(2), options.PastSkew)
+	assert.Equal(t, uint(1), options.Skew)
+	assert.Equal(t, common.EightDigits, options.CodeSize)
+	assert.Equal(t, common.SHA256Algorithm, options.Algorithm)
+
+	keyOptions, err := config.KeyOptions("foobar@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "example", keyOptions.Issuer)
+	assert.Equal(t, common.EightDigits, keyOptions.Options.CodeSize)
+
+	verifier, err := config.Verifier()
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), verifier.MaxFailures)
+	assert.Equal(t, time.Hour, verifier.LockoutDuration)
+}
+
+func TestLoadJsonFile(t *testing.T) {
+	path := writeFile(t, "otp.json", `{"issuer":"example","hotp":{"look_ahead":20}}`)
+
+	config := NewDefaultConfig()
+	require.NoError(t, config.LoadFile(path))
+	assert.Equal(t, "example", config.Issuer)
+	assert.Equal(t, 20, config.Hotp.LookAhead)
+	assert.Equal(t, 30, config.Totp.Period)
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		field   string
+	}{
+		{"unknown yaml key", "otp.yaml", "totp:\n  periods: 30\n", "totp.periods"},
+		{"unknown nested yaml key", "otp.yaml", "issuer: example\nqr:\n  size: 256\n  colour: red\n", "qr.colour"},
+		{"yaml type", "otp.yaml", "hotp:\n  look_ahead: many\n", "hotp.look_ahead"},
+		{"yaml flow type", "otp.yaml", "totp: {period: thirty}\n", "totp.period"},
+		{"unknown json key", "otp.json", `{"issuer":"example","foo":1}`, "foo"},
+		{"unknown nested json key", "otp.json", `{"issuer":"example","qr":{"size":256,"colour":"red"}}`, "qr.colour"},
+		{"unknown json key after nested", "otp.json", `{"totp":{"Period":30},"hotp":{"look_ahead":5,"window":{"size":1}}}`, "hotp.window"},
+		{"json type", "otp.json", `{"totp":{"period":"30"}}`, "totp.period"},
+		{"invalid digits", "otp.yaml", "digits: 12\n", "digits"},
+		{"invalid algorithm", "otp.json", `{"algorithm":"MD5"}`, "algorithm"},
+		{"zero period", "otp.yaml", "totp:\n  period: 0\n", "totp.period"},
+		{"negative skew", "otp.yaml", "totp:\n  skew: -1\n", "totp.skew"},
+		{"invalid duration", "otp.yaml", "lockout:\n  duration: soon\n", "lockout.duration"},
+		{"invalid recovery level", "otp.yaml", "qr:\n  recovery_level: extreme\n", "qr.recovery_level"},
+		{"extension", "otp.toml", "issuer = 'example'", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+
+			err := NewDefaultConfig().LoadFile(path)
+			require.ErrorIs(t, err, common.ErrorInvalidConfiguration)
+
+			var otpErr *common.OtpError
+			require.ErrorAs(t, err, &otpErr)
+			// errors that are not about a key name the file
+			if tt.field == "" {
+				tt.field = path
+			}
+			assert.Equal(t, tt.field, otpErr.Field)
+		})
+	}
+
+	err := NewDefaultConfig().LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, common.ErrorInvalidConfiguration)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "OTP_ISSUER", EnvName("issuer"))
+	assert.Equal(t, "OTP_LOCKOUT_MAX_FAILURES", EnvName("lockout.max_failures"))
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	path := writeFile(t, "otp.yaml", "issuer: file\ndigits: 8\ntotp:\n  period: 60\n")
+	t.Setenv("OTP_ISSUER", "env")
+	t.Setenv("OTP_TOTP_PERIOD", "45")
+	t.Setenv("OTP_LOCKOUT_DURATION", "5m")
+
+	configuration.NewWithDefaults()
+	config, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "env", config.Issuer)
+	assert.Equal(t, 8, config.Digits)
+	assert.Equal(t, 45, config.Totp.Period)
+	assert.Equal(t, "5m", config.Lockout.Duration)
+}
+
+func TestLoadEnvFromConfigurationVault(t *testing.T) {
+	service := configuration.NewWithDefaults()
+	require.NoError(t, service.UpsertKey("OTP_ALGORITHM", "SHA512"))
+
+	config := NewDefaultConfig()
+	require.NoError(t, config.LoadEnv(service))
+	assert.Equal(t, "SHA512", config.Algorithm)
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		err   error
+	}{
+		{"OTP_TOTP_SKEW", "one", common.ErrorInvalidConfiguration},
+		{"OTP_DIGITS", "3", common.ErrorInvalidCodeSize},
+		{"OTP_ALGORITHM", "MD5", common.ErrorUnknownAlgorithm},
+		{"OTP_QR_SIZE", "0", common.ErrorInvalidConfiguration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+
+			err := NewDefaultConfig().LoadEnv(configuration.NewWithDefaults())
+			assert.ErrorIs(t, err, common.ErrorInvalidConfiguration)
+			assert.ErrorIs(t, err, tt.err)
+			assert.ErrorContains(t, err, tt.name)
+		})
+	}
+}
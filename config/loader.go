@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to the upper cased key, with dots replaced by
+// underscores, to get the environment variable name of a key.
+const EnvPrefix = "OTP_"
+
+// Load builds the configuration from the defaults, then the file if path is
+// not empty and last the environment, each one overriding the previous.
+func Load(path string) (*Config, error) {
+	result := NewDefaultConfig()
+
+	if path != "" {
+		if err := result.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := result.LoadEnv(configuration.Get()); err != nil {
+		return nil, err
+	}
+
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// LoadFile overrides the configuration with the keys present in a json or
+// yaml file, unknown keys are rejected.
+func (c *Config) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return common.ErrorInvalidConfiguration.WithField(path).WithCause(err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = common.DecodeJSONStrict(content, c)
+
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return invalid(typeErr.Field, typeErr)
+		}
+
+		if err != nil {
+			if field := jsonUnknownKey(content, reflect.TypeOf(c), ""); field != "" {
+				return invalid(field, err)
+			}
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			if field := yamlErrorField(content, typeErr); field != "" {
+				return invalid(field, typeErr)
+			}
+		}
+	default:
+		err = errors.New("unsupported file extension, expected .json, .yaml or .yml")
+	}
+
+	if err != nil {
+		return common.ErrorInvalidConfiguration.WithField(path).WithCause(err)
+	}
+
+	return c.Validate()
+}
+
+// yamlErrorField returns the key of the first error of a yaml.TypeError,
+// the messages only carry the line so the key is looked up in the document.
+func yamlErrorField(content []byte, typeErr *yaml.TypeError) string {
+	if len(typeErr.Errors) == 0 {
+		return ""
+	}
+
+	var line int
+	if _, err := fmt.Sscanf(typeErr.Errors[0], "line %d:", &line); err != nil {
+		return ""
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return ""
+	}
+
+	return yamlKeyAt(&document, line, "")
+}
+
+// yamlKeyAt returns the dotted path of the innermost key, or value, at line.
+func yamlKeyAt(node *yaml.Node, line int, prefix string) string {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if key := yamlKeyAt(child, line, prefix); key != "" {
+				return key
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			path := key.Value
+			if prefix != "" {
+				path = prefix + "." + key.Value
+			}
+
+			if nested := yamlKeyAt(value, line, path); nested != "" {
+				return nested
+			}
+
+			if key.Line == line || value.Line == line {
+				return path
+			}
+		}
+	}
+
+	return ""
+}
+
+// jsonUnknownKey returns the dotted path of the first key of the document
+// that has no field in t, the decoder error only names the key itself.
+func jsonUnknownKey(content []byte, t reflect.Type, prefix string) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return ""
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return ""
+		}
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		field, ok := jsonField(t, key)
+		if !ok {
+			return path
+		}
+
+		if nested := jsonUnknownKey(value, field.Type, path); nested != "" {
+			return nested
+		}
+	}
+
+	return ""
+}
+
+// jsonField finds the field a key decodes into, matching the json name
+// case-insensitively like encoding/json does.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// LoadEnv overrides the configuration with the values the configuration
+// service has for each key, see EnvName. A nil service uses the global one.
+func (c *Config) LoadEnv(service *configuration.ConfigurationService) error {
+	if service == nil {
+		service = configuration.Get()
+	}
+
+	for _, s := range settings {
+		name := EnvName(s.key)
+
+		value := strings.TrimSpace(service.GetString(name))
+		if value == "" {
+			continue
+		}
+
+		if err := s.set(c, value); err != nil {
+			return invalid(name, err)
+		}
+
+		if err := s.check(c); err != nil {
+			return invalid(name, err)
+		}
+	}
+
+	return nil
+}
+
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
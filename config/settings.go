@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+var (
+	errNegative = errors.New("value cannot be negative")
+	errZero     = errors.New("value cannot be zero")
+)
+
+// setting ties a configuration key to how it is parsed from an environment
+// value and how it is validated.
+type setting struct {
+	key   string
+	set   func(c *Config, value string) error
+	check func(c *Config) error
+}
+
+var settings = []setting{
+	stringSetting("issuer", func(c *Config) *string { return &c.Issuer }, nil),
+	intSetting("digits", func(c *Config) *int { return &c.Digits }, func(value int) error {
+		_, err := common.NewPassCodeSize(value)
+		return err
+	}),
+	stringSetting("algorithm", func(c *Config) *string { return &c.Algorithm }, func(value string) error {
+		_, err := common.ParseAlgorithm(value)
+		return err
+	}),
+	int64Setting("totp.t0", func(c *Config) *int64 { return &c.Totp.T0 }),
+	intSetting("totp.period", func(c *Config) *int { return &c.Totp.Period }, positive),
+	intSetting("totp.skew", func(c *Config) *int { return &c.Totp.Skew }, notNegative),
+	intSetting("totp.past_skew", func(c *Config) *int { return &c.Totp.PastSkew }, notNegative),
+	intSetting("totp.future_skew", func(c *Config) *int { return &c.Totp.FutureSkew }, notNegative),
+	intSetting("totp.max_drift", func(c *Config) *int { return &c.Totp.MaxDrift }, notNegative),
+	intSetting("hotp.look_ahead", func(c *Config) *int { return &c.Hotp.LookAhead }, notNegative),
+	intSetting("lockout.max_failures", func(c *Config) *int { return &c.Lockout.MaxFailures }, notNegative),
+	stringSetting("lockout.duration", func(c *Config) *string { return &c.Lockout.Duration }, func(value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		if duration < 0 {
+			return errNegative
+		}
+
+		return nil
+	}),
+	intSetting("qr.size", func(c *Config) *int { return &c.Qr.Size }, func(value int) error {
+		if value == 0 {
+			return errZero
+		}
+
+		return nil
+	}),
+	stringSetting("qr.recovery_level", func(c *Config) *string { return &c.Qr.RecoveryLevel }, func(value string) error {
+		_, err := parseRecoveryLevel(value)
+		return err
+	}),
+}
+
+func stringSetting(key string, field func(c *Config) *string, check func(value string) error) setting {
+	return setting{
+		key: key,
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		check: func(c *Config) error {
+			if check == nil {
+				return nil
+			}
+
+			return check(*field(c))
+		},
+	}
+}
+
+func intSetting(key string, field func(c *Config) *int, check func(value int) error) setting {
+	return setting{
+		key: key,
+		set: func(c *Config, value string) error {
+			result, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+
+			*field(c) = result
+			return nil
+		},
+		check: func(c *Config) error {
+			return check(*field(c))
+		},
+	}
+}
+
+func int64Setting(key string, field func(c *Config) *int64) setting {
+	return setting{
+		key: key,
+		set: func(c *Config, value string) error {
+			result, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return err
+			}
+
+			*field(c) = result
+			return nil
+		},
+		check: func(c *Config) error {
+			return nil
+		},
+	}
+}
+
+func positive(value int) error {
+	if value <= 0 {
+		return errors.New("value must be greater than zero")
+	}
+
+	return nil
+}
+
+func notNegative(value int) error {
+	if value < 0 {
+		return errNegative
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+)
+
+const (
+	TotpType = "totp"
+	HotpType = "hotp"
+)
+
+// Credential is a single authenticator enrolled by a user, together with the
+// state that needs to be persisted between validations.
+type Credential struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Primary  bool                `json:"primary"`
+	Disabled bool                `json:"disabled"`
+	Key      *otp.OtpKey         `json:"key"`
+	Rotation *otp.SecretRotation `json:"rotation,omitempty"`
+	// Options holds the TOTP period and skew, the code size and algorithm are
+	// always read from the key.
+	Options *totp.TotpOptions `json:"options,omitempty"`
+	// Counter is the next HOTP counter expected from the token.
+	Counter uint64 `json:"counter"`
+	// LastStep is the last TOTP time step accepted, it stops a code from
+	// being used twice.
+	LastStep  uint64          `json:"last_step"`
+	Drift     totp.DriftState `json:"drift"`
+	CreatedAt time.Time       `json:"created_at"`
+	LastUsed  time.Time       `json:"last_used"`
+}
+
+func NewCredential(name string, key *otp.OtpKey) (*Credential, error) {
+	if key == nil {
+		return nil, common.ErrorNilOtpKey.WithField("key")
+	}
+
+	switch key.Type() {
+	case TotpType, HotpType:
+	default:
+		return nil, common.ErrorUnsupportedKeyType.WithField("key")
+	}
+
+	result := Credential{
+		ID:        newCredentialId(),
+		Name:      name,
+		Key:       key,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	return &result, nil
+}
+
+func (c *Credential) Type() string {
+	return c.Key.Type()
+}
+
+func (c *Credential) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", c.ID),
+		slog.String("name", c.Name),
+		slog.String("type", c.Type()),
+	)
+}
+
+// Rotate issues a new key for the credential, the previous secret is still
+// accepted until the grace period expires or the new key is used. The new
+// key counts its HOTP codes from zero in the rotation, Counter keeps
+// following the previous secret until the rotation completes.
+func (c *Credential) Rotate(gracePeriod time.Duration, t time.Time) (*otp.OtpKey, error) {
+	rotation, err := otp.RotateKey(c.currentKey(), gracePeriod, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// a pending rotation is completed, only the last two secrets are kept
+	if c.Rotation != nil {
+		c.Rotation.Complete()
+		c.completeRotation()
+	}
+
+	c.Rotation = rotation
+
+	return rotation.Key, nil
+}
+
+func (c *Credential) currentKey() *otp.OtpKey {
+	if c.Rotation != nil {
+		return c.Rotation.Key
+	}
+
+	return c.Key
+}
+
+// keyState returns the key the secret belongs to and the HOTP counter kept
+// for it.
+func (c *Credential) keyState(secret string) (*otp.OtpKey, *uint64) {
+	if c.Rotation != nil && c.Rotation.Key.Secret() == secret {
+		return c.Rotation.Key, &c.Rotation.Counter
+	}
+
+	return c.Key, &c.Counter
+}
+
+// completeRotation makes the rotated key the credential key once the
+// previous secret was destroyed.
+func (c *Credential) completeRotation() {
+	c.Key = c.Rotation.Key
+	c.Counter = c.Rotation.Counter
+	c.Rotation = nil
+}
+
+func (c *Credential) totpOptions(key *otp.OtpKey) (*totp.TotpOptions, error) {
+	keyOptions, err := key.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	options := totp.NewDefaultTotpOptions()
+	if c.Options != nil {
+		copied := *c.Options
+		options = &copied
+	}
+
+	options.CodeSize = keyOptions.CodeSize
+	options.Algorithm = keyOptions.Algorithm
+
+	return options, nil
+}
+
+func newCredentialId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,126 @@
+package credential
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTotpKey(t *testing.T) *otp.OtpKey {
+	key, err := totp.GenerateDefaultKey("foobar", "foobar@example.com")
+	require.NoError(t, err)
+
+	return key
+}
+
+func newHotpKey(t *testing.T, codeSize common.PassCodeSize) *otp.OtpKey {
+	key, err := hotp.GenerateKey(&otp.OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &otp.OtpOptions{CodeSize: codeSize},
+	})
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestNewCredential(t *testing.T) {
+	key := newTotpKey(t)
+
+	credential, err := NewCredential("phone", key)
+	require.NoError(t, err)
+	assert.Len(t, credential.ID, 32)
+	assert.Equal(t, "phone", credential.Name)
+	assert.Equal(t, TotpType, credential.Type())
+	assert.False(t, credential.CreatedAt.IsZero())
+}
+
+func TestNewCredentialWithInvalidKey(t *testing.T) {
+	credential, err := NewCredential("phone", nil)
+	assert.ErrorIs(t, err, common.ErrorNilOtpKey)
+	assert.Nil(t, credential)
+
+	key, err := otp.NewKeyFromUrl(url.URL{Scheme: "otpauth", Host: "motp", Path: "foobar:foobar@example.com"})
+	require.NoError(t, err)
+
+	credential, err = NewCredential("phone", key)
+	assert.ErrorIs(t, err, common.ErrorUnsupportedKeyType)
+	assert.Nil(t, credential)
+}
+
+func TestCredentialRotate(t *testing.T) {
+	key := newTotpKey(t)
+	credential, err := NewCredential("phone", key)
+	require.NoError(t, err)
+
+	newKey, err := credential.Rotate(time.Hour, time.Now().UTC())
+	require.NoError(t, err)
+	assert.NotEqual(t, key.Secret(), newKey.Secret())
+	assert.Equal(t, key, credential.Key)
+	assert.Equal(t, newKey, credential.currentKey())
+
+	// rotating again keeps the last two secrets
+	credential.Rotation.Counter = 7
+	latest, err := credential.Rotate(time.Hour, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, newKey, credential.Key)
+	assert.Equal(t, uint64(7), credential.Counter)
+	assert.Equal(t, newKey.Secret(), credential.Rotation.Previous.Value())
+	assert.Equal(t, latest, credential.currentKey())
+}
+
+func TestCredentialTotpOptions(t *testing.T) {
+	key, err := totp.GenerateKey(&otp.OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &otp.OtpOptions{CodeSize: common.EightDigits, Algorithm: common.SHA256Algorithm},
+	})
+	require.NoError(t, err)
+
+	credential, err := NewCredential("phone", key)
+	require.NoError(t, err)
+	credential.Options = &totp.TotpOptions{Period: 60, Skew: 2}
+
+	options, err := credential.totpOptions(credential.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint(60), options.Period)
+	assert.Equal(t, uint(2), options.Skew)
+	assert.Equal(t, common.EightDigits, options.CodeSize)
+	assert.Equal(t, common.SHA256Algorithm, options.Algorithm)
+	assert.Equal(t, common.PassCodeSize(0), credential.Options.CodeSize)
+}
+
+func TestCredentialJSON(t *testing.T) {
+	credential, err := NewCredential("phone", newTotpKey(t))
+	require.NoError(t, err)
+	credential.Options = &totp.TotpOptions{Period: 60, Skew: 2}
+	credential.Drift = totp.DriftState{Offset: -1}
+	_, err = credential.Rotate(time.Hour, time.Now().UTC())
+	require.NoError(t, err)
+
+	data, err := json.Marshal(credential)
+	require.NoError(t, err)
+
+	var decoded Credential
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, credential.ID, decoded.ID)
+	assert.Equal(t, credential.Key.String(), decoded.Key.String())
+	assert.Equal(t, credential.Rotation.Key.String(), decoded.Rotation.Key.String())
+	assert.Equal(t, credential.Rotation.Previous.Value(), decoded.Rotation.Previous.Value())
+	assert.Equal(t, uint(60), decoded.Options.Period)
+	assert.Equal(t, credential.Drift, decoded.Drift)
+
+	expected, err := credential.totpOptions(credential.Key)
+	require.NoError(t, err)
+	options, err := decoded.totpOptions(decoded.Key)
+	require.NoError(t, err)
+	assert.Equal(t, expected, options)
+}
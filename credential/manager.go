@@ -0,0 +1,153 @@
+package credential
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/audit"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+// Manager runs the credential lifecycle operations, enrollment, rotation,
+// removal and verification, recording each of them in the audit sink.
+type Manager struct {
+	Verifier *Verifier
+	Audit    audit.AuditSink
+}
+
+func NewManager(sink audit.AuditSink) *Manager {
+	verifier := NewVerifier()
+	verifier.Audit = sink
+
+	result := Manager{
+		Verifier: verifier,
+		Audit:    sink,
+	}
+
+	return &result
+}
+
+func (m *Manager) Enroll(user *UserCredentials, name string, key *otp.OtpKey, t time.Time) (*Credential, error) {
+	return m.EnrollContext(context.Background(), user, name, key, t)
+}
+
+func (m *Manager) EnrollContext(ctx context.Context, user *UserCredentials, name string, key *otp.OtpKey, t time.Time) (*Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	credential, err := user.Enroll(name, key)
+	if err != nil {
+		return nil, err
+	}
+
+	m.audit(ctx, credentialEvent(audit.EnrollmentEvent, user, credential, t))
+
+	return credential, nil
+}
+
+func (m *Manager) Rotate(user *UserCredentials, id string, gracePeriod time.Duration, t time.Time) (*otp.OtpKey, error) {
+	return m.RotateContext(context.Background(), user, id, gracePeriod, t)
+}
+
+func (m *Manager) RotateContext(ctx context.Context, user *UserCredentials, id string, gracePeriod time.Duration, t time.Time) (*otp.OtpKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	credential, err := user.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := credential.Rotate(gracePeriod, t)
+	if err != nil {
+		return nil, err
+	}
+
+	event := credentialEvent(audit.SecretRotatedEvent, user, credential, t)
+	event.Details = map[string]string{"grace_expires_at": credential.Rotation.ExpiresAt.UTC().Format(time.RFC3339)}
+	m.audit(ctx, event)
+
+	return key, nil
+}
+
+func (m *Manager) Remove(user *UserCredentials, id string, t time.Time) error {
+	return m.RemoveContext(context.Background(), user, id, t)
+}
+
+func (m *Manager) RemoveContext(ctx context.Context, user *UserCredentials, id string, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	credential, err := user.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := user.Remove(id); err != nil {
+		return err
+	}
+
+	m.audit(ctx, credentialEvent(audit.CredentialDeletedEvent, user, credential, t))
+
+	return nil
+}
+
+// RecoveryCodeUsed records that the user signed in with a recovery code,
+// the codes themselves are checked by the application.
+func (m *Manager) RecoveryCodeUsed(user *UserCredentials, t time.Time) error {
+	return m.RecoveryCodeUsedContext(context.Background(), user, t)
+}
+
+func (m *Manager) RecoveryCodeUsedContext(ctx context.Context, user *UserCredentials, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.audit(ctx, audit.NewEvent(audit.RecoveryCodeUsedEvent, user.UserId, t))
+
+	return nil
+}
+
+func (m *Manager) Verify(user *UserCredentials, code string, t time.Time) (*Credential, error) {
+	return m.Verifier.Verify(user, code, t)
+}
+
+func (m *Manager) VerifyContext(ctx context.Context, user *UserCredentials, code string, t time.Time) (*Credential, error) {
+	return m.Verifier.VerifyContext(ctx, user, code, t)
+}
+
+func (m *Manager) audit(ctx context.Context, event audit.Event) {
+	if m.Audit == nil {
+		return
+	}
+
+	if err := m.Audit.Write(ctx, event); err != nil {
+		m.logger(ctx).Error("failed to write audit event",
+			slog.String("type", string(event.Type)),
+			slog.Any("error", err))
+	}
+}
+
+// logger uses the verifier logger, a manager built without a verifier logs
+// to the one carried by ctx.
+func (m *Manager) logger(ctx context.Context) *slog.Logger {
+	if m.Verifier != nil {
+		return m.Verifier.logger(ctx)
+	}
+
+	return otp.LoggerFromContext(ctx)
+}
+
+func credentialEvent(eventType audit.EventType, user *UserCredentials, credential *Credential, t time.Time) audit.Event {
+	event := audit.NewKeyEvent(eventType, credential.currentKey(), t)
+	event.CredentialId = credential.ID
+	if user.UserId != "" {
+		event.UserId = user.UserId
+	}
+
+	return event
+}
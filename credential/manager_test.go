@@ -0,0 +1,97 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/audit"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerAuditsLifecycle(t *testing.T) {
+	sink := audit.NewMemorySink()
+	manager := NewManager(sink)
+	manager.Verifier.MaxFailures = 1
+	user := NewUserCredentials("foobar@example.com")
+	now := time.Now().UTC()
+
+	phone, err := manager.Enroll(user, "phone", newTotpKey(t), now)
+	require.NoError(t, err)
+
+	matched, err := manager.Verify(user, totpCode(t, phone.Key, now), now)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+
+	_, err = manager.Rotate(user, phone.ID, time.Hour, now)
+	require.NoError(t, err)
+
+	_, err = manager.Verify(user, "000000", now.Add(time.Minute))
+	require.NoError(t, err)
+
+	require.NoError(t, manager.Remove(user, phone.ID, now))
+	require.NoError(t, manager.RecoveryCodeUsed(user, now))
+
+	events := sink.Events()
+	types := []audit.EventType{}
+	for _, event := range events {
+		types = append(types, event.Type)
+		assert.Equal(t, "foobar@example.com", event.UserId)
+	}
+
+	assert.Equal(t, []audit.EventType{
+		audit.EnrollmentEvent,
+		audit.VerificationSucceededEvent,
+		audit.SecretRotatedEvent,
+		audit.VerificationFailedEvent,
+		audit.LockoutEvent,
+		audit.CredentialDeletedEvent,
+		audit.RecoveryCodeUsedEvent,
+	}, types)
+	assert.Equal(t, phone.ID, events[0].CredentialId)
+	assert.Equal(t, "foobar", events[0].Issuer)
+	assert.Equal(t, "totp", events[0].KeyType)
+	assert.Equal(t, "1", events[3].Details["failed_attempts"])
+	assert.NotEmpty(t, events[4].Details["locked_until"])
+	assert.NotEmpty(t, events[2].Details["grace_expires_at"])
+}
+
+func TestManagerReturnsLifecycleErrors(t *testing.T) {
+	manager := NewManager(nil)
+	user := NewUserCredentials("foobar@example.com")
+
+	_, err := manager.Enroll(user, "phone", nil, time.Now())
+	assert.Error(t, err)
+
+	_, err = manager.Rotate(user, "unknown", time.Hour, time.Now())
+	assert.Error(t, err)
+
+	assert.Error(t, manager.Remove(user, "unknown", time.Now()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, manager.RecoveryCodeUsedContext(ctx, user, time.Now()), context.Canceled)
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(context.Context, audit.Event) error {
+	return errors.New("disk full")
+}
+
+func TestManagerWithoutVerifierLogsAuditErrors(t *testing.T) {
+	var buffer bytes.Buffer
+	ctx := otp.ContextWithLogger(context.Background(), slog.New(slog.NewTextHandler(&buffer, nil)))
+	manager := &Manager{Audit: failingSink{}}
+	user := NewUserCredentials("foobar@example.com")
+
+	_, err := manager.EnrollContext(ctx, user, "phone", newTotpKey(t), time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, buffer.String(), "failed to write audit event")
+	assert.Contains(t, buffer.String(), "disk full")
+}
@@ -0,0 +1,193 @@
+package credential
+
+import (
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+type UserCredentials struct {
+	UserId         string        `json:"user_id"`
+	Credentials    []*Credential `json:"credentials"`
+	FailedAttempts uint          `json:"failed_attempts"`
+	LockedUntil    time.Time     `json:"locked_until"`
+}
+
+func NewUserCredentials(userId string) *UserCredentials {
+	result := UserCredentials{
+		UserId:      userId,
+		Credentials: []*Credential{},
+	}
+
+	return &result
+}
+
+// Enroll creates a credential for the key and adds it, the first credential
+// enrolled becomes the primary one.
+func (u *UserCredentials) Enroll(name string, key *otp.OtpKey) (*Credential, error) {
+	credential, err := NewCredential(name, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.Add(credential); err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func (u *UserCredentials) Add(credential *Credential) error {
+	if credential == nil {
+		return common.ErrorNilCredential.WithField("credential")
+	}
+
+	if credential.ID == "" {
+		credential.ID = newCredentialId()
+	}
+
+	if _, err := u.Get(credential.ID); err == nil {
+		return common.ErrorDuplicateCredential.WithField("ID")
+	}
+
+	u.Credentials = append(u.Credentials, credential)
+	if credential.Primary {
+		return u.SetPrimary(credential.ID)
+	}
+
+	if u.Primary() == nil && !credential.Disabled {
+		credential.Primary = true
+	}
+
+	return nil
+}
+
+func (u *UserCredentials) Get(id string) (*Credential, error) {
+	for _, credential := range u.Credentials {
+		if credential.ID == id {
+			return credential, nil
+		}
+	}
+
+	return nil, common.ErrorCredentialNotFound.WithField("id")
+}
+
+func (u *UserCredentials) Remove(id string) error {
+	for i, credential := range u.Credentials {
+		if credential.ID == id {
+			u.Credentials = append(u.Credentials[:i], u.Credentials[i+1:]...)
+			if credential.Primary {
+				u.promotePrimary()
+			}
+
+			return nil
+		}
+	}
+
+	return common.ErrorCredentialNotFound.WithField("id")
+}
+
+func (u *UserCredentials) Rename(id string, name string) error {
+	credential, err := u.Get(id)
+	if err != nil {
+		return err
+	}
+
+	credential.Name = name
+
+	return nil
+}
+
+func (u *UserCredentials) SetPrimary(id string) error {
+	credential, err := u.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if credential.Disabled {
+		return common.ErrorCredentialDisabled.WithField("id")
+	}
+
+	for _, c := range u.Credentials {
+		c.Primary = false
+	}
+	credential.Primary = true
+
+	return nil
+}
+
+func (u *UserCredentials) Primary() *Credential {
+	for _, credential := range u.Credentials {
+		if credential.Primary {
+			return credential
+		}
+	}
+
+	return nil
+}
+
+func (u *UserCredentials) Disable(id string) error {
+	credential, err := u.Get(id)
+	if err != nil {
+		return err
+	}
+
+	credential.Disabled = true
+	if credential.Primary {
+		credential.Primary = false
+		u.promotePrimary()
+	}
+
+	return nil
+}
+
+func (u *UserCredentials) Enable(id string) error {
+	credential, err := u.Get(id)
+	if err != nil {
+		return err
+	}
+
+	credential.Disabled = false
+	if u.Primary() == nil {
+		credential.Primary = true
+	}
+
+	return nil
+}
+
+// Active returns the enabled credentials with the primary one first.
+func (u *UserCredentials) Active() []*Credential {
+	result := []*Credential{}
+	for _, credential := range u.Credentials {
+		if credential.Disabled {
+			continue
+		}
+
+		if credential.Primary {
+			result = append([]*Credential{credential}, result...)
+		} else {
+			result = append(result, credential)
+		}
+	}
+
+	return result
+}
+
+func (u *UserCredentials) IsLocked(t time.Time) bool {
+	return t.Before(u.LockedUntil)
+}
+
+func (u *UserCredentials) Unlock() {
+	u.FailedAttempts = 0
+	u.LockedUntil = time.Time{}
+}
+
+func (u *UserCredentials) promotePrimary() {
+	for _, credential := range u.Credentials {
+		if !credential.Disabled {
+			credential.Primary = true
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package credential
+
+import (
+	"testing"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollSetsFirstCredentialAsPrimary(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	token, err := user.Enroll("token", newHotpKey(t, common.EightDigits))
+	require.NoError(t, err)
+
+	assert.True(t, phone.Primary)
+	assert.False(t, token.Primary)
+	assert.Equal(t, phone, user.Primary())
+	assert.Len(t, user.Credentials, 2)
+}
+
+func TestAddCredential(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+
+	assert.ErrorIs(t, user.Add(nil), common.ErrorNilCredential)
+
+	credential, err := NewCredential("phone", newTotpKey(t))
+	require.NoError(t, err)
+	require.NoError(t, user.Add(credential))
+	assert.ErrorIs(t, user.Add(credential), common.ErrorDuplicateCredential)
+
+	primary, err := NewCredential("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+	primary.Primary = true
+	require.NoError(t, user.Add(primary))
+
+	assert.Equal(t, primary, user.Primary())
+	assert.False(t, credential.Primary)
+}
+
+func TestSetPrimary(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	token, err := user.Enroll("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+
+	require.NoError(t, user.SetPrimary(token.ID))
+	assert.True(t, token.Primary)
+	assert.False(t, phone.Primary)
+	assert.Equal(t, []*Credential{token, phone}, user.Active())
+
+	assert.ErrorIs(t, user.SetPrimary("unknown"), common.ErrorCredentialNotFound)
+
+	require.NoError(t, user.Disable(phone.ID))
+	assert.ErrorIs(t, user.SetPrimary(phone.ID), common.ErrorCredentialDisabled)
+}
+
+func TestDisableAndEnable(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	token, err := user.Enroll("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+
+	require.NoError(t, user.Disable(phone.ID))
+	assert.True(t, phone.Disabled)
+	assert.False(t, phone.Primary)
+	assert.Equal(t, token, user.Primary())
+	assert.Equal(t, []*Credential{token}, user.Active())
+
+	require.NoError(t, user.Disable(token.ID))
+	assert.Nil(t, user.Primary())
+	assert.Empty(t, user.Active())
+
+	require.NoError(t, user.Enable(phone.ID))
+	assert.Equal(t, phone, user.Primary())
+
+	assert.ErrorIs(t, user.Enable("unknown"), common.ErrorCredentialNotFound)
+	assert.ErrorIs(t, user.Disable("unknown"), common.ErrorCredentialNotFound)
+}
+
+func TestRenameAndRemove(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	token, err := user.Enroll("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+
+	require.NoError(t, user.Rename(token.ID, "yubikey"))
+	assert.Equal(t, "yubikey", token.Name)
+	assert.ErrorIs(t, user.Rename("unknown", "foo"), common.ErrorCredentialNotFound)
+
+	require.NoError(t, user.Remove(phone.ID))
+	assert.Equal(t, []*Credential{token}, user.Credentials)
+	assert.Equal(t, token, user.Primary())
+	assert.ErrorIs(t, user.Remove(phone.ID), common.ErrorCredentialNotFound)
+
+	_, err = user.Get(phone.ID)
+	assert.ErrorIs(t, err, common.ErrorCredentialNotFound)
+}
@@ -0,0 +1,271 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/audit"
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+)
+
+const (
+	DefaultHotpLookAhead = hotp.DefaultLookAhead
+	// DefaultMaxFailures and DefaultLockoutDuration are suggested values
+	// for the lockout, NewVerifier leaves it disabled.
+	DefaultMaxFailures     = 5
+	DefaultLockoutDuration = 15 * time.Minute
+)
+
+type Verifier struct {
+	// HotpLookAhead is how many counters past the expected one are tried to
+	// resynchronize a token that was pressed without logging in.
+	HotpLookAhead uint
+	MaxDrift      uint
+	// MaxFailures is how many wrong codes in a row lock the user out for
+	// LockoutDuration, zero disables the lockout. Errors, e.g. a policy
+	// violation, are not counted.
+	MaxFailures uint
+	// LockoutDuration defaults to DefaultLockoutDuration.
+	LockoutDuration time.Duration
+	// Policy overrides otp.DefaultPolicy for the credentials verified.
+	Policy *otp.Policy
+	// Normalizer overrides otp.DefaultCodeNormalizer for the codes verified.
+	Normalizer *otp.CodeNormalizer
+	Logger     *slog.Logger
+	Observer   otp.Observer
+	Audit      audit.AuditSink
+}
+
+func NewVerifier() *Verifier {
+	result := Verifier{
+		HotpLookAhead: DefaultHotpLookAhead,
+		MaxDrift:      totp.DefaultMaxDrift,
+	}
+
+	return &result
+}
+
+// Verify checks the code against all the active credentials of the user and
+// returns the one that matched, or nil if none did.
+func (v *Verifier) Verify(user *UserCredentials, code string, t time.Time) (*Credential, error) {
+	return v.VerifyContext(context.Background(), user, code, t)
+}
+
+// VerifyContext works like Verify, when ctx is done it returns the context
+// error without counting a failed attempt.
+func (v *Verifier) VerifyContext(ctx context.Context, user *UserCredentials, code string, t time.Time) (*Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, common.ErrorNilUserCredentials.WithField("user")
+	}
+
+	logger := v.logger(ctx).With(slog.String("user_id", user.UserId))
+	if user.IsLocked(t) {
+		logger.Warn("verification attempted while locked out", slog.Time("locked_until", user.LockedUntil))
+		return nil, common.ErrorLockedOut.WithField("user")
+	}
+
+	var verifyErr error
+	wrongCode := false
+	for _, credential := range user.Active() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// a match already consumed the code, it is returned even if ctx is
+		// done by now
+		valid, err := v.VerifyCredentialContext(ctx, credential, code, t)
+		if valid {
+			user.FailedAttempts = 0
+			logger.Info("credential verified", slog.Any("credential", credential))
+			v.audit(ctx, credentialEvent(audit.VerificationSucceededEvent, user, credential, t))
+			return credential, nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		// a code of a different length was meant for another credential
+		switch {
+		case err == nil, errors.Is(err, common.ErrorWrongCodeSize):
+			wrongCode = true
+		case verifyErr == nil:
+			verifyErr = err
+		}
+	}
+
+	// only wrong codes count towards the lockout, not the errors
+	if !wrongCode {
+		if verifyErr != nil {
+			logger.Warn("credential verification error", slog.Any("error", verifyErr))
+		}
+
+		return nil, verifyErr
+	}
+
+	user.FailedAttempts++
+	logger.Warn("credential verification failed",
+		slog.Uint64("failed_attempts", uint64(user.FailedAttempts)),
+		slog.Any("error", verifyErr))
+
+	event := audit.NewEvent(audit.VerificationFailedEvent, user.UserId, t)
+	event.Details = map[string]string{"failed_attempts": strconv.FormatUint(uint64(user.FailedAttempts), 10)}
+	v.audit(ctx, event)
+
+	if v.MaxFailures > 0 && user.FailedAttempts >= v.MaxFailures {
+		start := time.Now()
+		user.FailedAttempts = 0
+		duration := v.LockoutDuration
+		if duration <= 0 {
+			duration = DefaultLockoutDuration
+		}
+
+		user.LockedUntil = t.Add(duration)
+		logger.Warn("user locked out", slog.Time("locked_until", user.LockedUntil))
+		otp.Notify(ctx, v.Observer, otp.Event{
+			Kind:    otp.LockoutEvent,
+			Outcome: otp.LockedOutOutcome,
+		}, start)
+
+		event := audit.NewEvent(audit.LockoutEvent, user.UserId, t)
+		event.Details = map[string]string{"locked_until": user.LockedUntil.UTC().Format(time.RFC3339)}
+		v.audit(ctx, event)
+	}
+
+	return nil, verifyErr
+}
+
+func (v *Verifier) VerifyCredential(credential *Credential, code string, t time.Time) (bool, error) {
+	return v.VerifyCredentialContext(context.Background(), credential, code, t)
+}
+
+func (v *Verifier) VerifyCredentialContext(ctx context.Context, credential *Credential, code string, t time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if credential == nil {
+		return false, common.ErrorNilCredential.WithField("credential")
+	}
+
+	if credential.Disabled {
+		return false, common.ErrorCredentialDisabled.WithField("credential")
+	}
+
+	validate := func(secret string) (bool, error) {
+		switch credential.Type() {
+		case TotpType:
+			return v.verifyTotp(ctx, credential, code, secret, t)
+		case HotpType:
+			return v.verifyHotp(ctx, credential, code, secret)
+		default:
+			return false, common.ErrorUnsupportedKeyType
+		}
+	}
+
+	var valid bool
+	var err error
+	if credential.Rotation != nil {
+		valid, err = credential.Rotation.Validate(t, validate)
+		if credential.Rotation.Previous == nil {
+			credential.completeRotation()
+			v.logger(ctx).Info("credential secret rotation completed", slog.Any("credential", credential))
+		}
+	} else {
+		valid, err = validate(credential.Key.Secret())
+	}
+
+	if valid {
+		credential.LastUsed = t
+	}
+
+	return valid, err
+}
+
+func (v *Verifier) verifyTotp(ctx context.Context, credential *Credential, code string, secret string, t time.Time) (bool, error) {
+	key, _ := credential.keyState(secret)
+	options, err := credential.totpOptions(key)
+	if err != nil {
+		return false, err
+	}
+
+	validator := totp.NewValidator(options)
+	validator.MaxDrift = v.MaxDrift
+	validator.Policy = v.Policy
+	validator.Normalizer = v.Normalizer
+	validator.Logger = v.Logger
+	validator.Observer = v.Observer
+
+	drift := credential.Drift
+	step, valid, err := validator.ValidateStepContext(ctx, code, secret, t, &drift)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	if !credential.LastUsed.IsZero() && step <= credential.LastStep {
+		v.logger(ctx).Warn("totp code replay rejected", slog.Any("credential", credential))
+		return false, nil
+	}
+
+	credential.LastStep = step
+	credential.Drift = drift
+
+	return true, nil
+}
+
+// verifyHotp checks the code against the counter of the key the secret
+// belongs to, during a rotation each secret keeps its own counter.
+func (v *Verifier) verifyHotp(ctx context.Context, credential *Credential, code string, secret string) (bool, error) {
+	key, expected := credential.keyState(secret)
+	options, err := key.Options()
+	if err != nil {
+		return false, err
+	}
+
+	validator := hotp.Validator{
+		LookAhead:  v.HotpLookAhead,
+		Policy:     v.Policy,
+		Normalizer: v.Normalizer,
+		Logger:     v.Logger,
+		Observer:   v.Observer,
+	}
+
+	counter, valid, err := validator.ValidateContext(ctx, code, secret, *expected, options)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	*expected = counter + 1
+
+	return true, nil
+}
+
+func (v *Verifier) audit(ctx context.Context, event audit.Event) {
+	if v.Audit == nil {
+		return
+	}
+
+	if err := v.Audit.Write(ctx, event); err != nil {
+		v.logger(ctx).Error("failed to write audit event",
+			slog.String("type", string(event.Type)),
+			slog.Any("error", err))
+	}
+}
+
+func (v *Verifier) logger(ctx context.Context) *slog.Logger {
+	if v.Logger != nil {
+		return v.Logger
+	}
+
+	return otp.LoggerFromContext(ctx)
+}
@@ -0,0 +1,368 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hotpCode(t *testing.T, key *otp.OtpKey, counter uint64) string {
+	options, err := key.Options()
+	require.NoError(t, err)
+
+	code, err := hotp.GenerateCode(key.Secret(), counter, options)
+	require.NoError(t, err)
+
+	return code
+}
+
+func totpCode(t *testing.T, key *otp.OtpKey, now time.Time) string {
+	code, err := totp.GenerateCode(key.Secret(), now, totp.NewDefaultTotpOptions())
+	require.NoError(t, err)
+
+	return code
+}
+
+func TestVerifyMatchesAnyActiveCredential(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	token, err := user.Enroll("token", newHotpKey(t, common.EightDigits))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	now := time.Now().UTC()
+
+	matched, err := verifier.Verify(user, totpCode(t, phone.Key, now), now)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+
+	matched, err = verifier.Verify(user, hotpCode(t, token.Key, 0), now)
+	require.NoError(t, err)
+	assert.Equal(t, token, matched)
+	assert.Equal(t, uint64(1), token.Counter)
+	assert.Equal(t, now, token.LastUsed)
+
+	matched, err = verifier.Verify(user, "000000000", now)
+	assert.NoError(t, err)
+	assert.Nil(t, matched)
+}
+
+func TestVerifySkipsDisabledCredentials(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	require.NoError(t, user.Disable(phone.ID))
+	verifier := NewVerifier()
+	now := time.Now().UTC()
+
+	matched, err := verifier.Verify(user, totpCode(t, phone.Key, now), now)
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+
+	valid, err := verifier.VerifyCredential(phone, totpCode(t, phone.Key, now), now)
+	assert.ErrorIs(t, err, common.ErrorCredentialDisabled)
+	assert.False(t, valid)
+
+	_, err = verifier.Verify(nil, "123456", now)
+	assert.ErrorIs(t, err, common.ErrorNilUserCredentials)
+}
+
+func TestVerifyRejectsReplayedTotpCode(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	now := time.Now().UTC()
+	code := totpCode(t, phone.Key, now)
+
+	matched, err := verifier.Verify(user, code, now)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+
+	matched, err = verifier.Verify(user, code, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+
+	// the previous step is no longer accepted either
+	previous := totpCode(t, phone.Key, now.Add(-30*time.Second))
+	matched, err = verifier.Verify(user, previous, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+
+	next := now.Add(30 * time.Second)
+	matched, err = verifier.Verify(user, totpCode(t, phone.Key, next), next)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+}
+
+func TestVerifyHotpCounter(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	token, err := user.Enroll("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	verifier.HotpLookAhead = 2
+	now := time.Now().UTC()
+
+	// the token was pressed twice without logging in
+	matched, err := verifier.Verify(user, hotpCode(t, token.Key, 2), now)
+	require.NoError(t, err)
+	assert.Equal(t, token, matched)
+	assert.Equal(t, uint64(3), token.Counter)
+
+	// already used counters are rejected
+	matched, err = verifier.Verify(user, hotpCode(t, token.Key, 1), now)
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+
+	// beyond the look ahead window
+	matched, err = verifier.Verify(user, hotpCode(t, token.Key, 6), now)
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+	assert.Equal(t, uint64(3), token.Counter)
+}
+
+func TestVerifyWithRotatedCredential(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	now := time.Now().UTC()
+	oldKey := phone.Key
+
+	newKey, err := phone.Rotate(time.Hour, now)
+	require.NoError(t, err)
+
+	matched, err := verifier.Verify(user, totpCode(t, oldKey, now), now)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+	assert.NotNil(t, phone.Rotation)
+
+	next := now.Add(30 * time.Second)
+	matched, err = verifier.Verify(user, totpCode(t, newKey, next), next)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+	assert.Nil(t, phone.Rotation)
+	assert.Equal(t, newKey, phone.Key)
+
+	after := next.Add(30 * time.Second)
+	matched, err = verifier.Verify(user, totpCode(t, oldKey, after), after)
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+}
+
+func TestVerifyWithRotatedHotpCredential(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	token, err := user.Enroll("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+	token.Counter = 50
+	verifier := NewVerifier()
+	now := time.Now().UTC()
+	oldKey := token.Key
+
+	newKey, err := token.Rotate(time.Hour, now)
+	require.NoError(t, err)
+
+	// the previous secret moves its own counter only
+	matched, err := verifier.Verify(user, hotpCode(t, oldKey, 51), now)
+	require.NoError(t, err)
+	assert.Equal(t, token, matched)
+	assert.Equal(t, uint64(52), token.Counter)
+	assert.Equal(t, uint64(0), token.Rotation.Counter)
+
+	// the new token starts at counter zero
+	matched, err = verifier.Verify(user, hotpCode(t, newKey, 0), now)
+	require.NoError(t, err)
+	assert.Equal(t, token, matched)
+	assert.Nil(t, token.Rotation)
+	assert.Equal(t, newKey, token.Key)
+	assert.Equal(t, uint64(1), token.Counter)
+
+	matched, err = verifier.Verify(user, hotpCode(t, newKey, 1), now)
+	require.NoError(t, err)
+	assert.Equal(t, token, matched)
+	assert.Equal(t, uint64(2), token.Counter)
+
+	matched, err = verifier.Verify(user, hotpCode(t, oldKey, 52), now)
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+}
+
+func TestVerifyLocksOutAfterTooManyFailures(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	verifier.MaxFailures = 3
+	verifier.LockoutDuration = time.Minute
+	now := time.Now().UTC()
+	wrong := "00000"
+
+	for i := 0; i < 3; i++ {
+		matched, err := verifier.Verify(user, wrong, now)
+		require.NoError(t, err)
+		require.Nil(t, matched)
+	}
+
+	assert.True(t, user.IsLocked(now))
+	assert.Equal(t, now.Add(time.Minute), user.LockedUntil)
+
+	matched, err := verifier.Verify(user, totpCode(t, phone.Key, now), now)
+	assert.ErrorIs(t, err, common.ErrorLockedOut)
+	assert.Nil(t, matched)
+
+	later := now.Add(2 * time.Minute)
+	matched, err = verifier.Verify(user, totpCode(t, phone.Key, later), later)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+	assert.Equal(t, uint(0), user.FailedAttempts)
+
+	user.LockedUntil = later.Add(time.Hour)
+	user.Unlock()
+	assert.False(t, user.IsLocked(later))
+}
+
+func TestVerifyLockoutDisabledByDefault(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	_, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	for i := 0; i < 2*DefaultMaxFailures; i++ {
+		_, err := NewVerifier().Verify(user, "00000", now)
+		require.NoError(t, err)
+	}
+
+	assert.False(t, user.IsLocked(now))
+	assert.Equal(t, uint(2*DefaultMaxFailures), user.FailedAttempts)
+}
+
+func TestVerifyLockoutIgnoresErrors(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	verifier.MaxFailures = 1
+	verifier.Policy = &otp.Policy{MinSecretSize: 64}
+	now := time.Now().UTC()
+
+	for i := 0; i < 3; i++ {
+		matched, err := verifier.Verify(user, totpCode(t, phone.Key, now), now)
+		assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+		assert.Nil(t, matched)
+	}
+
+	assert.False(t, user.IsLocked(now))
+	assert.Equal(t, uint(0), user.FailedAttempts)
+}
+
+func TestVerifyContextCancelled(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	matched, err := NewVerifier().VerifyContext(ctx, user, totpCode(t, phone.Key, now), now)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, matched)
+	assert.Equal(t, uint(0), user.FailedAttempts)
+	assert.True(t, phone.LastUsed.IsZero())
+}
+
+func TestVerifyContextCancelledAfterMatch(t *testing.T) {
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	user.FailedAttempts = 2
+	now := time.Now().UTC()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the code is accepted and stored before ctx is cancelled
+	verifier := NewVerifier()
+	verifier.Observer = otp.ObserverFunc(func(context.Context, otp.Event) { cancel() })
+
+	matched, err := verifier.VerifyContext(ctx, user, totpCode(t, phone.Key, now), now)
+	require.NoError(t, err)
+	assert.Equal(t, phone, matched)
+	assert.Equal(t, uint(0), user.FailedAttempts)
+	assert.Equal(t, now, phone.LastUsed)
+}
+
+func TestVerifyLogsWithoutSecretsOrCodes(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	verifier.MaxFailures = 1
+	verifier.Logger = slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}))
+	now := time.Now().UTC()
+	code := totpCode(t, phone.Key, now)
+
+	_, err = verifier.Verify(user, code, now)
+	require.NoError(t, err)
+	_, err = verifier.Verify(user, code, now)
+	require.NoError(t, err)
+	_, err = verifier.Verify(user, code, now)
+	require.ErrorIs(t, err, common.ErrorLockedOut)
+
+	logs := buffer.String()
+	assert.Contains(t, logs, `"msg":"credential verified"`)
+	assert.Contains(t, logs, `"msg":"totp code replay rejected"`)
+	assert.Contains(t, logs, `"msg":"credential verification failed"`)
+	assert.Contains(t, logs, `"msg":"user locked out"`)
+	assert.Contains(t, logs, `"msg":"verification attempted while locked out"`)
+	assert.Contains(t, logs, phone.ID)
+	assert.NotContains(t, logs, phone.Key.Secret())
+	assert.NotContains(t, logs, code)
+}
+
+func TestVerifyNotifiesObserver(t *testing.T) {
+	events := []otp.Event{}
+	user := NewUserCredentials("foobar@example.com")
+	phone, err := user.Enroll("phone", newTotpKey(t))
+	require.NoError(t, err)
+	_, err = user.Enroll("token", newHotpKey(t, common.SixDigits))
+	require.NoError(t, err)
+	verifier := NewVerifier()
+	verifier.MaxFailures = 1
+	verifier.Observer = otp.ObserverFunc(func(_ context.Context, event otp.Event) {
+		events = append(events, event)
+	})
+	now := time.Now().UTC()
+
+	_, err = verifier.Verify(user, totpCode(t, phone.Key, now), now)
+	require.NoError(t, err)
+	_, err = verifier.Verify(user, "0000", now)
+	require.NoError(t, err)
+
+	// the short code fails on both credentials before locking the user out
+	require.Len(t, events, 4)
+	assert.Equal(t, otp.ValidateEvent, events[0].Kind)
+	assert.Equal(t, "totp", events[0].Type)
+	assert.Equal(t, otp.SuccessOutcome, events[0].Outcome)
+	assert.Equal(t, otp.ErrorOutcome, events[1].Outcome)
+	assert.Equal(t, "hotp", events[2].Type)
+	assert.Equal(t, otp.LockoutEvent, events[3].Kind)
+	assert.Equal(t, otp.LockedOutOutcome, events[3].Outcome)
+}
@@ -0,0 +1,309 @@
+package delivered
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+const (
+	DefaultTTL         = 10 * time.Minute
+	DefaultCooldown    = time.Minute
+	DefaultMaxSends    = 5
+	DefaultQuotaWindow = time.Hour
+	DefaultMaxAttempts = 5
+
+	// eventType keeps the delivered codes out of the hotp metrics.
+	eventType = "delivered"
+)
+
+// Service issues numeric codes sent by email or SMS and verifies them, each
+// code is bound to a purpose and a recipient and can only be used once.
+type Service struct {
+	Sender   Sender
+	Store    Store
+	CodeSize common.PassCodeSize
+	TTL      time.Duration
+	// Cooldown is the minimum time between two codes for the same purpose
+	// and recipient.
+	Cooldown time.Duration
+	// MaxSends codes can be sent to a recipient, for any purpose, in every
+	// QuotaWindow, zero disables the quota.
+	MaxSends    int
+	QuotaWindow time.Duration
+	// MaxAttempts wrong codes discard the challenge.
+	MaxAttempts int
+	// Secret derives the codes with HOTP and a random counter when set,
+	// otherwise codes are random digits.
+	Secret *otp.OtpSecret
+	// Normalizer overrides otp.DefaultCodeNormalizer for the verified codes.
+	Normalizer *otp.CodeNormalizer
+	Logger     *slog.Logger
+	// Observer gets the issue and verify events as the "delivered" type,
+	// apart from the hotp and totp ones.
+	Observer otp.Observer
+}
+
+func NewService(sender Sender) *Service {
+	result := Service{
+		Sender:      sender,
+		Store:       NewMemoryStore(),
+		CodeSize:    common.SixDigits,
+		TTL:         DefaultTTL,
+		Cooldown:    DefaultCooldown,
+		MaxSends:    DefaultMaxSends,
+		QuotaWindow: DefaultQuotaWindow,
+		MaxAttempts: DefaultMaxAttempts,
+	}
+
+	return &result
+}
+
+// Issue generates a code and sends it, it replaces any pending code for the
+// same purpose and recipient.
+func (s *Service) Issue(ctx context.Context, channel Channel, recipient string, purpose string, t time.Time) (*Challenge, error) {
+	start := time.Now()
+	challenge, err := s.issue(ctx, channel, recipient, purpose, t)
+	otp.Notify(ctx, s.Observer, otp.Event{
+		Kind:    otp.GenerateEvent,
+		Type:    eventType,
+		Outcome: otp.OutcomeOf(err == nil, err),
+		Err:     err,
+	}, start)
+
+	return challenge, err
+}
+
+func (s *Service) issue(ctx context.Context, channel Channel, recipient string, purpose string, t time.Time) (*Challenge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(recipient) == "" {
+		return nil, common.ErrorEmptyRecipient.WithField("recipient")
+	}
+
+	if strings.TrimSpace(purpose) == "" {
+		return nil, common.ErrorEmptyPurpose.WithField("purpose")
+	}
+
+	logger := s.logger(ctx).With(
+		slog.String("channel", string(channel)),
+		slog.String("purpose", purpose))
+
+	challenge := Challenge{
+		Purpose:   purpose,
+		Recipient: recipient,
+		Channel:   channel,
+		SentAt:    t,
+		ExpiresAt: t.Add(s.TTL),
+	}
+
+	code, err := s.newCode(&challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	// the limits are checked and the challenge stored in a single store
+	// operation before sending, so concurrent requests can not get past the
+	// cooldown or the quota
+	limits := SendLimits{Cooldown: s.Cooldown, MaxSends: s.MaxSends, QuotaStart: t.Add(-s.QuotaWindow)}
+	previous, err := s.Store.Reserve(ctx, &challenge, limits)
+	switch {
+	case errors.Is(err, common.ErrorResendCooldown):
+		logger.Debug("code resend refused during cooldown")
+		return nil, err
+	case errors.Is(err, common.ErrorQuotaExceeded):
+		logger.Warn("code send quota exceeded")
+		return nil, err
+	case err != nil:
+		return nil, err
+	}
+
+	err = s.Sender.Send(ctx, Message{
+		Channel:   channel,
+		Recipient: recipient,
+		Purpose:   purpose,
+		Code:      code,
+		ExpiresAt: challenge.ExpiresAt,
+	})
+	if err != nil {
+		logger.Warn("code delivery failed", slog.Any("error", err))
+		return nil, errors.Join(err, s.Store.Release(ctx, &challenge, previous))
+	}
+
+	logger.Info("code sent", slog.Time("expires_at", challenge.ExpiresAt))
+
+	return &challenge, nil
+}
+
+// Verify checks the code sent for the purpose to the recipient, a valid code
+// is consumed and a challenge with too many wrong attempts is discarded.
+func (s *Service) Verify(recipient string, purpose string, code string, t time.Time) (bool, error) {
+	return s.VerifyContext(context.Background(), recipient, purpose, code, t)
+}
+
+// VerifyContext works like Verify, ctx is passed to the store and carries
+// the logger and observer used when the service has none.
+func (s *Service) VerifyContext(ctx context.Context, recipient string, purpose string, code string, t time.Time) (bool, error) {
+	start := time.Now()
+	valid, err := s.verify(ctx, recipient, purpose, code, t)
+	otp.Notify(ctx, s.Observer, otp.Event{
+		Kind:    otp.ValidateEvent,
+		Type:    eventType,
+		Outcome: otp.OutcomeOf(valid, err),
+		Err:     err,
+	}, start)
+
+	return valid, err
+}
+
+func (s *Service) verify(ctx context.Context, recipient string, purpose string, code string, t time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	logger := s.logger(ctx).With(slog.String("purpose", purpose))
+	normalized := s.Normalizer.Normalize(code)
+
+	// the challenge is checked and updated in a single store operation so
+	// concurrent verifications can not both accept the code or undercount
+	// the attempts
+	var valid bool
+	var verifyErr error
+	err := s.Store.Consume(ctx, purpose, recipient, func(challenge *Challenge) *Challenge {
+		if challenge == nil {
+			verifyErr = common.ErrorChallengeNotFound.WithField("recipient")
+			return nil
+		}
+
+		if !t.Before(challenge.ExpiresAt) {
+			logger.Debug("expired code used")
+			verifyErr = common.ErrorChallengeExpired.WithField("code")
+			return nil
+		}
+
+		if s.MaxAttempts > 0 && challenge.Attempts >= s.MaxAttempts {
+			verifyErr = common.ErrorTooManyAttempts.WithField("code")
+			return nil
+		}
+
+		valid, verifyErr = s.matches(challenge, normalized)
+		if verifyErr != nil {
+			return challenge
+		}
+
+		if valid {
+			logger.Info("code verified")
+			return nil
+		}
+
+		challenge.Attempts++
+		logger.Warn("wrong code", slog.Int("attempts", challenge.Attempts))
+		if s.MaxAttempts > 0 && challenge.Attempts >= s.MaxAttempts {
+			return nil
+		}
+
+		return challenge
+	})
+
+	return valid && err == nil, firstError(err, verifyErr)
+}
+
+func (s *Service) newCode(challenge *Challenge) (string, error) {
+	if s.Secret != nil {
+		counter := make([]byte, 8)
+		if _, err := rand.Read(counter); err != nil {
+			return "", err
+		}
+
+		challenge.Counter = binary.BigEndian.Uint64(counter)
+
+		generator, err := otp.NewGenerator(s.Secret.Value(), s.otpOptions(challenge))
+		if err != nil {
+			return "", err
+		}
+
+		return generator.Generate(challenge.Counter), nil
+	}
+
+	if err := s.CodeSize.Validate(); err != nil {
+		return "", common.WithField(err, "CodeSize")
+	}
+
+	value, err := rand.Int(rand.Reader, new(big.Int).SetUint64(s.CodeSize.Modulus()))
+	if err != nil {
+		return "", err
+	}
+
+	code := s.CodeSize.Format(value.Uint64())
+	challenge.CodeHash = hashCode(challenge, code)
+
+	return code, nil
+}
+
+func (s *Service) matches(challenge *Challenge, code string) (bool, error) {
+	if len(code) != s.CodeSize.Length() {
+		return false, nil
+	}
+
+	if s.Secret != nil {
+		generator, err := otp.NewGenerator(s.Secret.Value(), s.otpOptions(challenge))
+		if err != nil {
+			return false, err
+		}
+		generator.SetNormalizer(s.Normalizer)
+
+		return generator.Validate(code, challenge.Counter)
+	}
+
+	return subtle.ConstantTimeCompare(hashCode(challenge, code), challenge.CodeHash) == 1, nil
+}
+
+// otpOptions binds HOTP codes to the purpose and recipient so a code sent for
+// one flow does not validate in another.
+func (s *Service) otpOptions(challenge *Challenge) *otp.OtpOptions {
+	binding := fmt.Sprintf("%d:%s%s", len(challenge.Purpose), challenge.Purpose, challenge.Recipient)
+
+	return &otp.OtpOptions{CodeSize: s.CodeSize, Binding: binding}
+}
+
+// hashCode binds the code hash to the purpose and recipient so a stored hash
+// cannot be moved to another challenge.
+func hashCode(challenge *Challenge, code string) []byte {
+	hash := sha256.New()
+	for _, value := range []string{challenge.Purpose, challenge.Recipient, code} {
+		_ = binary.Write(hash, binary.BigEndian, uint32(len(value)))
+		hash.Write([]byte(value))
+	}
+
+	return hash.Sum(nil)
+}
+
+// firstError returns the store error in preference to the verification one.
+func firstError(storeErr error, err error) error {
+	if storeErr != nil {
+		return storeErr
+	}
+
+	return err
+}
+
+func (s *Service) logger(ctx context.Context) *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+
+	return otp.LoggerFromContext(ctx)
+}
@@ -0,0 +1,387 @@
+package delivered
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	recipient = "foobar@example.com"
+	login     = "login"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestService() (*Service, *MemorySender) {
+	sender := NewMemorySender()
+
+	return NewService(sender), sender
+}
+
+func lastCode(t *testing.T, sender *MemorySender) string {
+	message, ok := sender.Last(recipient)
+	require.True(t, ok)
+
+	return message.Code
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	service, sender := newTestService()
+
+	challenge, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(DefaultTTL), challenge.ExpiresAt)
+	assert.NotEmpty(t, challenge.CodeHash)
+
+	message, ok := sender.Last(recipient)
+	require.True(t, ok)
+	assert.Equal(t, EmailChannel, message.Channel)
+	assert.Equal(t, login, message.Purpose)
+	assert.Len(t, message.Code, 6)
+	assert.NotContains(t, string(challenge.CodeHash), message.Code)
+
+	// bound to the purpose
+	_, err = service.Verify(recipient, "reset_password", message.Code, now)
+	assert.ErrorIs(t, err, common.ErrorChallengeNotFound)
+
+	valid, err := service.Verify(recipient, login, " "+message.Code+" ", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// single use
+	_, err = service.Verify(recipient, login, message.Code, now.Add(time.Minute))
+	assert.ErrorIs(t, err, common.ErrorChallengeNotFound)
+}
+
+func TestHotpDerivedCodes(t *testing.T) {
+	service, sender := newTestService()
+	service.Secret = otp.NewRandomOtpSecret(20)
+	service.CodeSize = common.EightDigits
+
+	challenge, err := service.Issue(context.Background(), SmsChannel, recipient, login, now)
+	require.NoError(t, err)
+	assert.Empty(t, challenge.CodeHash)
+
+	code := lastCode(t, sender)
+	assert.Len(t, code, 8)
+
+	valid, err := service.Verify(recipient, login, "123", now)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	// codes are bound to the purpose and recipient
+	unbound, err := hotp.Validate(code, challenge.Counter, service.Secret.Value(), &otp.OtpOptions{CodeSize: common.EightDigits})
+	require.NoError(t, err)
+	assert.False(t, unbound)
+
+	valid, err = service.Verify(recipient, login, code, now)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestServiceNotifiesObserver(t *testing.T) {
+	events := []otp.Event{}
+	global := 0
+	otp.SetObserver(otp.ObserverFunc(func(context.Context, otp.Event) { global++ }))
+	t.Cleanup(func() { otp.SetObserver(nil) })
+
+	service, sender := newTestService()
+	service.Secret = otp.NewRandomOtpSecret(20)
+	service.Observer = otp.ObserverFunc(func(_ context.Context, event otp.Event) {
+		events = append(events, event)
+	})
+
+	_, err := service.Issue(context.Background(), SmsChannel, recipient, login, now)
+	require.NoError(t, err)
+	valid, err := service.Verify(recipient, login, lastCode(t, sender), now)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// the hotp derived codes are not reported as hotp events
+	assert.Zero(t, global)
+	require.Len(t, events, 2)
+	assert.Equal(t, otp.GenerateEvent, events[0].Kind)
+	assert.Equal(t, "delivered", events[0].Type)
+	assert.Equal(t, otp.SuccessOutcome, events[0].Outcome)
+	assert.Equal(t, otp.ValidateEvent, events[1].Kind)
+	assert.Equal(t, "delivered", events[1].Type)
+	assert.Equal(t, otp.SuccessOutcome, events[1].Outcome)
+}
+
+func TestVerifyNormalizesCode(t *testing.T) {
+	service, sender := newTestService()
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+
+	valid, err := service.Verify(recipient, login, otp.DisplayCode(lastCode(t, sender)), now)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerifyWithNormalizer(t *testing.T) {
+	service, sender := newTestService()
+	service.Normalizer = &otp.CodeNormalizer{}
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+
+	valid, err := service.Verify(recipient, login, otp.DisplayCode(lastCode(t, sender)), now)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = service.Verify(recipient, login, " "+lastCode(t, sender)+" ", now)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerifyExpiredCode(t *testing.T) {
+	service, sender := newTestService()
+
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+
+	_, err = service.Verify(recipient, login, lastCode(t, sender), now.Add(DefaultTTL))
+	assert.ErrorIs(t, err, common.ErrorChallengeExpired)
+
+	_, err = service.Verify(recipient, login, lastCode(t, sender), now)
+	assert.ErrorIs(t, err, common.ErrorChallengeNotFound)
+}
+
+func TestVerifyTooManyAttempts(t *testing.T) {
+	service, sender := newTestService()
+	service.MaxAttempts = 2
+
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+	code := lastCode(t, sender)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < 2; i++ {
+		valid, err := service.Verify(recipient, login, wrong, now)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	}
+
+	_, err = service.Verify(recipient, login, code, now)
+	assert.ErrorIs(t, err, common.ErrorChallengeNotFound)
+}
+
+func TestVerifyConcurrently(t *testing.T) {
+	service, sender := newTestService()
+	service.MaxAttempts = 3
+
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+	code := lastCode(t, sender)
+
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if valid, _ := service.Verify(recipient, login, code, now); valid {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, accepted.Load())
+
+	// concurrent wrong codes can not get past the attempt limit
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, login, now.Add(time.Hour))
+	require.NoError(t, err)
+	code = lastCode(t, sender)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	var wrongAttempts atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.Verify(recipient, login, wrong, now.Add(time.Hour)); err == nil {
+				wrongAttempts.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 3, wrongAttempts.Load())
+
+	_, err = service.Verify(recipient, login, code, now.Add(time.Hour))
+	assert.ErrorIs(t, err, common.ErrorChallengeNotFound)
+}
+
+func TestResendCooldown(t *testing.T) {
+	service, sender := newTestService()
+
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+	first := lastCode(t, sender)
+
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, login, now.Add(20*time.Second))
+	assert.ErrorIs(t, err, common.ErrorResendCooldown)
+	assert.ErrorContains(t, err, "retry in 40s")
+
+	// other purposes are not affected
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, "reset_password", now.Add(20*time.Second))
+	require.NoError(t, err)
+
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, login, now.Add(DefaultCooldown))
+	require.NoError(t, err)
+	second := sender.Messages()[2].Code
+
+	// the new code replaces the old one
+	if first != second {
+		valid, err := service.Verify(recipient, login, first, now.Add(DefaultCooldown))
+		require.NoError(t, err)
+		assert.False(t, valid)
+	}
+
+	valid, err := service.Verify(recipient, login, second, now.Add(DefaultCooldown))
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSendQuota(t *testing.T) {
+	service, _ := newTestService()
+	service.MaxSends = 2
+	service.Cooldown = 0
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Issue(context.Background(), SmsChannel, recipient, login, now.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+
+	_, err := service.Issue(context.Background(), SmsChannel, recipient, "reset_password", now.Add(2*time.Minute))
+	assert.ErrorIs(t, err, common.ErrorQuotaExceeded)
+
+	_, err = service.Issue(context.Background(), SmsChannel, "other@example.com", login, now.Add(2*time.Minute))
+	assert.NoError(t, err)
+
+	_, err = service.Issue(context.Background(), SmsChannel, recipient, login, now.Add(DefaultQuotaWindow))
+	assert.ErrorIs(t, err, common.ErrorQuotaExceeded)
+
+	_, err = service.Issue(context.Background(), SmsChannel, recipient, login, now.Add(DefaultQuotaWindow+time.Minute))
+	assert.NoError(t, err)
+}
+
+func TestIssueConcurrently(t *testing.T) {
+	service, _ := newTestService()
+	service.Cooldown = 0
+
+	var delivered atomic.Int32
+	service.Sender = SenderFunc(func(context.Context, Message) error {
+		time.Sleep(10 * time.Millisecond)
+		delivered.Add(1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = service.Issue(context.Background(), SmsChannel, recipient, login, now)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, service.MaxSends, delivered.Load())
+
+	// with a cooldown only one of the concurrent requests is sent
+	service.Cooldown = DefaultCooldown
+	delivered.Store(0)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = service.Issue(context.Background(), SmsChannel, "other@example.com", login, now)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, delivered.Load())
+}
+
+func TestIssueErrors(t *testing.T) {
+	service, sender := newTestService()
+
+	_, err := service.Issue(context.Background(), EmailChannel, " ", login, now)
+	assert.ErrorIs(t, err, common.ErrorEmptyRecipient)
+
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, "", now)
+	assert.ErrorIs(t, err, common.ErrorEmptyPurpose)
+
+	failure := errors.New("smtp down")
+	service.Sender = SenderFunc(func(context.Context, Message) error { return failure })
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	assert.ErrorIs(t, err, failure)
+
+	// a failed delivery neither starts the cooldown nor counts for the quota
+	_, err = service.Verify(recipient, login, "123456", now)
+	assert.ErrorIs(t, err, common.ErrorChallengeNotFound)
+	sends, err := service.Store.CountSends(context.Background(), recipient, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, sends)
+
+	// a failed resend restores the pending challenge
+	service.Sender = sender
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+	code := lastCode(t, sender)
+
+	service.Sender = SenderFunc(func(context.Context, Message) error { return failure })
+	_, err = service.Issue(context.Background(), EmailChannel, recipient, login, now.Add(time.Hour))
+	assert.ErrorIs(t, err, failure)
+
+	valid, err := service.Verify(recipient, login, code, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestContextCancelled(t *testing.T) {
+	service, sender := newTestService()
+	_, err := service.Issue(context.Background(), EmailChannel, recipient, login, now)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = service.Issue(ctx, EmailChannel, "other@example.com", login, now)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, sender.Messages(), 1)
+
+	valid, err := service.VerifyContext(ctx, recipient, login, lastCode(t, sender), now)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, valid)
+
+	// the challenge is still pending
+	valid, err = service.Verify(recipient, login, lastCode(t, sender), now)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestLogSender(t *testing.T) {
+	var buf bytes.Buffer
+	sender := &LogSender{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	err := sender.Send(context.Background(), Message{Channel: SmsChannel, Recipient: "+15550100", Purpose: login, Code: "424242"})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "code=424242")
+	assert.Contains(t, buf.String(), "channel=sms")
+}
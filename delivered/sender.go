@@ -0,0 +1,99 @@
+package delivered
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+type Channel string
+
+const (
+	EmailChannel Channel = "email"
+	SmsChannel   Channel = "sms"
+)
+
+// Message is what a Sender delivers, it is the only place the code exists in
+// clear text.
+type Message struct {
+	Channel   Channel
+	Recipient string
+	Purpose   string
+	Code      string
+	ExpiresAt time.Time
+}
+
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type SenderFunc func(ctx context.Context, message Message) error
+
+func (f SenderFunc) Send(ctx context.Context, message Message) error {
+	return f(ctx, message)
+}
+
+// MemorySender keeps the messages instead of sending them, for tests.
+type MemorySender struct {
+	lock     sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(_ context.Context, message Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.messages = append(s.messages, message)
+
+	return nil
+}
+
+func (s *MemorySender) Messages() []Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]Message{}, s.messages...)
+}
+
+// Last returns the last message sent to the recipient.
+func (s *MemorySender) Last(recipient string) (Message, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].Recipient == recipient {
+			return s.messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
+
+// LogSender writes the messages, code included, to the logger, it is meant
+// for local development only.
+type LogSender struct {
+	Logger *slog.Logger
+}
+
+func (s *LogSender) Send(ctx context.Context, message Message) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = otp.LoggerFromContext(ctx)
+	}
+
+	logger.InfoContext(ctx, "one-time code sent",
+		slog.String("channel", string(message.Channel)),
+		slog.String("recipient", message.Recipient),
+		slog.String("purpose", message.Purpose),
+		slog.String("code", message.Code),
+		slog.Time("expires_at", message.ExpiresAt))
+
+	return nil
+}
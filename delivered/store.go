@@ -0,0 +1,236 @@
+package delivered
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+// Challenge is an issued code waiting to be verified, only a hash of a
+// random code, or the counter of a HOTP derived one, is kept.
+type Challenge struct {
+	Purpose   string
+	Recipient string
+	Channel   Channel
+	CodeHash  []byte
+	Counter   uint64
+	SentAt    time.Time
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// SendLimits are the limits Store.Reserve enforces, zero values disable them.
+type SendLimits struct {
+	// Cooldown is the minimum time between the pending challenge and a new
+	// one for the same purpose and recipient.
+	Cooldown time.Duration
+	// MaxSends is the most codes sent to the recipient, for any purpose,
+	// since QuotaStart.
+	MaxSends   int
+	QuotaStart time.Time
+}
+
+// Check returns the error for the first limit the new challenge breaks, for
+// stores implementing Reserve, sends is the count since QuotaStart.
+func (l SendLimits) Check(challenge *Challenge, pending *Challenge, sends int) error {
+	if pending != nil && challenge.SentAt.Before(pending.SentAt.Add(l.Cooldown)) {
+		wait := pending.SentAt.Add(l.Cooldown).Sub(challenge.SentAt).Round(time.Second)
+		return common.ErrorResendCooldown.WithField("recipient").WithCause(fmt.Errorf("retry in %s", wait))
+	}
+
+	if l.MaxSends > 0 && sends >= l.MaxSends {
+		return common.ErrorQuotaExceeded.WithField("recipient").WithCause(fmt.Errorf("%d codes sent", sends))
+	}
+
+	return nil
+}
+
+// Store keeps the pending challenges, one per purpose and recipient, and the
+// send history used for the quotas, implementations backed by a database
+// should honour the context deadline.
+type Store interface {
+	// Get returns nil when there is no challenge.
+	Get(ctx context.Context, purpose string, recipient string) (*Challenge, error)
+	Put(ctx context.Context, challenge *Challenge) error
+	Delete(ctx context.Context, purpose string, recipient string) error
+	// Consume runs fn atomically on the challenge, fn receives nil when
+	// there is none and the challenge it returns replaces the stored one,
+	// nil removes it. Verification relies on it for single use codes and
+	// the attempt limit, a database store would use a transaction or a
+	// conditional update.
+	Consume(ctx context.Context, purpose string, recipient string, fn func(challenge *Challenge) *Challenge) error
+	CountSends(ctx context.Context, recipient string, since time.Time) (int, error)
+	AddSend(ctx context.Context, recipient string, t time.Time) error
+	// Reserve atomically checks the limits, see SendLimits.Check, then
+	// stores the challenge in place of the pending one and records the
+	// send. It returns the replaced challenge so a failed delivery can be
+	// undone with Release.
+	Reserve(ctx context.Context, challenge *Challenge, limits SendLimits) (*Challenge, error)
+	// Release undoes a Reserve whose delivery failed, the previous
+	// challenge is restored if the reserved one is still stored and the
+	// send is forgotten.
+	Release(ctx context.Context, challenge *Challenge, previous *Challenge) error
+}
+
+type challengeKey struct {
+	purpose   string
+	recipient string
+}
+
+type MemoryStore struct {
+	lock       sync.Mutex
+	challenges map[challengeKey]Challenge
+	sends      map[string][]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		challenges: map[challengeKey]Challenge{},
+		sends:      map[string][]time.Time{},
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, purpose string, recipient string) (*Challenge, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	challenge, ok := s.challenges[challengeKey{purpose, recipient}]
+	if !ok {
+		return nil, nil
+	}
+
+	return &challenge, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, challenge *Challenge) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.challenges[challengeKey{challenge.Purpose, challenge.Recipient}] = *challenge
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, purpose string, recipient string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.challenges, challengeKey{purpose, recipient})
+
+	return nil
+}
+
+func (s *MemoryStore) Consume(_ context.Context, purpose string, recipient string, fn func(challenge *Challenge) *Challenge) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := challengeKey{purpose, recipient}
+
+	var current *Challenge
+	if challenge, ok := s.challenges[key]; ok {
+		current = &challenge
+	}
+
+	next := fn(current)
+	if next == nil {
+		delete(s.challenges, key)
+		return nil
+	}
+
+	s.challenges[key] = *next
+
+	return nil
+}
+
+func (s *MemoryStore) CountSends(_ context.Context, recipient string, since time.Time) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.countSends(recipient, since), nil
+}
+
+func (s *MemoryStore) countSends(recipient string, since time.Time) int {
+	count := 0
+	for _, t := range s.sends[recipient] {
+		if !t.Before(since) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// AddSend records a send and forgets the ones older than a day, quota
+// windows longer than that need another store.
+func (s *MemoryStore) AddSend(_ context.Context, recipient string, t time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.addSend(recipient, t)
+
+	return nil
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, challenge *Challenge, limits SendLimits) (*Challenge, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := challengeKey{challenge.Purpose, challenge.Recipient}
+
+	var previous *Challenge
+	if pending, ok := s.challenges[key]; ok {
+		previous = &pending
+	}
+
+	if err := limits.Check(challenge, previous, s.countSends(challenge.Recipient, limits.QuotaStart)); err != nil {
+		return nil, err
+	}
+
+	s.challenges[key] = *challenge
+	s.addSend(challenge.Recipient, challenge.SentAt)
+
+	return previous, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, challenge *Challenge, previous *Challenge) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := challengeKey{challenge.Purpose, challenge.Recipient}
+	if stored, ok := s.challenges[key]; ok && sameChallenge(&stored, challenge) {
+		if previous != nil {
+			s.challenges[key] = *previous
+		} else {
+			delete(s.challenges, key)
+		}
+	}
+
+	sends := s.sends[challenge.Recipient]
+	for i := len(sends) - 1; i >= 0; i-- {
+		if sends[i].Equal(challenge.SentAt) {
+			s.sends[challenge.Recipient] = append(sends[:i], sends[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func sameChallenge(a *Challenge, b *Challenge) bool {
+	return a.SentAt.Equal(b.SentAt) && a.Counter == b.Counter && bytes.Equal(a.CodeHash, b.CodeHash)
+}
+
+func (s *MemoryStore) addSend(recipient string, t time.Time) {
+	sends := s.sends[recipient][:0]
+	for _, sent := range s.sends[recipient] {
+		if t.Sub(sent) < 24*time.Hour {
+			sends = append(sends, sent)
+		}
+	}
+
+	s.sends[recipient] = append(sends, t)
+}
@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/cjlapao/common-go-identity-otp/common"
 )
@@ -33,12 +34,22 @@ func EncodeQuery(v url.Values) string {
 	return buf.String()
 }
 
+// PadSecret normalizes a base32 secret as typed by a user, spaces and dashes
+// are dropped, letters upper cased and the padding recomputed.
 func PadSecret(secret string) string {
+	secret = strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+
+		return r
+	}, secret)
+
+	secret = strings.TrimRight(secret, "=")
 	if secret == "" {
 		return ""
 	}
 
-	secret = strings.TrimSpace(secret)
 	if n := len(secret) % common.MODULUS_SIZE; n != 0 {
 		secret += strings.Repeat("=", common.MODULUS_SIZE-n)
 	}
@@ -13,6 +13,12 @@ func TestPadSecret(t *testing.T) {
 	assert.Equal(t, "TE======", secret)
 }
 
+func TestPadSecretIsLenient(t *testing.T) {
+	assert.Equal(t, "GEZDGNBV", PadSecret(" gezd-gnbv\n"))
+	assert.Equal(t, "GEZDGNBVGY======", PadSecret("GEZD GNBV GY=="))
+	assert.Equal(t, "", PadSecret(" = "))
+}
+
 func TestPadSecretWithEmptySecret(t *testing.T) {
 	secret := PadSecret("")
 
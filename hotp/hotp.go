@@ -1,17 +1,35 @@
 package hotp
 
 import (
-	"github.com/cjlapao/common-go-identity-otp/common"
+	"context"
+	"log/slog"
+	"time"
+
 	"github.com/cjlapao/common-go-identity-otp/otp"
 )
 
 func GenerateCode(secret string, counter uint64, options *otp.OtpOptions) (string, error) {
-	switch options.Algorithm {
-	case common.SHA256Algorithm, common.SHA512Algorithm:
-		options.Algorithm = common.SHA1Algorithm
+	return GenerateCodeContext(context.Background(), secret, counter, options)
+}
+
+// GenerateCodeContext works like GenerateCode, it fails if ctx is done and
+// reports to the logger and observer carried by ctx.
+func GenerateCodeContext(ctx context.Context, secret string, counter uint64, options *otp.OtpOptions) (string, error) {
+	if options == nil {
+		options = otp.NewDefaultOtpOptions()
 	}
 
-	return otp.GenerateCode(secret, counter, options)
+	start := time.Now()
+	code, err := generateCode(ctx, secret, counter, options)
+	otp.Notify(ctx, nil, otp.Event{
+		Kind:      otp.GenerateEvent,
+		Type:      "hotp",
+		Outcome:   otp.OutcomeOf(err == nil, err),
+		Algorithm: options.Algorithm.String(),
+		Err:       err,
+	}, start)
+
+	return code, err
 }
 
 func GenerateCodeDefault(secret string, counter uint64) (string, error) {
@@ -19,12 +37,27 @@ func GenerateCodeDefault(secret string, counter uint64) (string, error) {
 }
 
 func Validate(code string, counter uint64, secret string, options *otp.OtpOptions) (bool, error) {
-	switch options.Algorithm {
-	case common.SHA256Algorithm, common.SHA512Algorithm:
-		options.Algorithm = common.SHA1Algorithm
+	return ValidateContext(context.Background(), code, counter, secret, options)
+}
+
+// ValidateContext works like Validate, it fails if ctx is done and reports to
+// the logger and observer carried by ctx.
+func ValidateContext(ctx context.Context, code string, counter uint64, secret string, options *otp.OtpOptions) (bool, error) {
+	if options == nil {
+		options = otp.NewDefaultOtpOptions()
 	}
 
-	return otp.ValidateCode(code, counter, secret, options)
+	start := time.Now()
+	valid, err := validate(ctx, code, counter, secret, options)
+	otp.Notify(ctx, nil, otp.Event{
+		Kind:      otp.ValidateEvent,
+		Type:      "hotp",
+		Outcome:   otp.OutcomeOf(valid, err),
+		Algorithm: options.Algorithm.String(),
+		Err:       err,
+	}, start)
+
+	return valid, err
 }
 
 func ValidateDefault(code string, counter uint64, secret string) (bool, error) {
@@ -35,6 +68,10 @@ func GenerateKey(opts *otp.OtpKeyOptions) (*otp.OtpKey, error) {
 	return otp.GenerateKey("hotp", opts)
 }
 
+func GenerateKeyContext(ctx context.Context, opts *otp.OtpKeyOptions) (*otp.OtpKey, error) {
+	return otp.GenerateKeyContext(ctx, "hotp", opts)
+}
+
 func GenerateDefaultKey(issuer, userId string) (*otp.OtpKey, error) {
 	options := otp.OtpKeyOptions{
 		Issuer:  issuer,
@@ -45,3 +82,31 @@ func GenerateDefaultKey(issuer, userId string) (*otp.OtpKey, error) {
 
 	return otp.GenerateKey("hotp", &options)
 }
+
+// generateCode and validate use the generator and not the otp functions, so
+// only the hotp event is sent to the observer.
+func generateCode(ctx context.Context, secret string, counter uint64, options *otp.OtpOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	generator, err := otp.NewGenerator(secret, options)
+	if err != nil {
+		return "", err
+	}
+
+	code := generator.Generate(counter)
+	otp.LoggerFromContext(ctx).Debug("hotp code generated", slog.Uint64("counter", counter))
+
+	return code, nil
+}
+
+func validate(ctx context.Context, code string, counter uint64, secret string, options *otp.OtpOptions) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	_, valid, err := (&Validator{}).validateWindow(code, secret, counter, options)
+
+	return valid, err
+}
@@ -1,6 +1,7 @@
 package hotp
 
 import (
+	"context"
 	"encoding/base32"
 	"testing"
 
@@ -32,6 +33,34 @@ var (
 		{8, "399871", common.SHA1Algorithm, sha1Secret},
 		{9, "520489", common.SHA1Algorithm, sha1Secret},
 	}
+
+	// RFC 4226 only has SHA-1 vectors, these use the same secret and were
+	// computed with Python's hmac and hashlib modules
+	sha256TestMatrix = []RfcTestMatrixEntry{
+		{0, "875740", common.SHA256Algorithm, sha1Secret},
+		{1, "247374", common.SHA256Algorithm, sha1Secret},
+		{2, "254785", common.SHA256Algorithm, sha1Secret},
+		{3, "496144", common.SHA256Algorithm, sha1Secret},
+		{4, "480556", common.SHA256Algorithm, sha1Secret},
+		{5, "697997", common.SHA256Algorithm, sha1Secret},
+		{6, "191609", common.SHA256Algorithm, sha1Secret},
+		{7, "579288", common.SHA256Algorithm, sha1Secret},
+		{8, "895912", common.SHA256Algorithm, sha1Secret},
+		{9, "184989", common.SHA256Algorithm, sha1Secret},
+	}
+
+	sha512TestMatrix = []RfcTestMatrixEntry{
+		{0, "125165", common.SHA512Algorithm, sha1Secret},
+		{1, "342147", common.SHA512Algorithm, sha1Secret},
+		{2, "730102", common.SHA512Algorithm, sha1Secret},
+		{3, "778726", common.SHA512Algorithm, sha1Secret},
+		{4, "937510", common.SHA512Algorithm, sha1Secret},
+		{5, "848329", common.SHA512Algorithm, sha1Secret},
+		{6, "266680", common.SHA512Algorithm, sha1Secret},
+		{7, "588359", common.SHA512Algorithm, sha1Secret},
+		{8, "039399", common.SHA512Algorithm, sha1Secret},
+		{9, "643409", common.SHA512Algorithm, sha1Secret},
+	}
 )
 
 func TestGenerateRFCMatrix(t *testing.T) {
@@ -48,7 +77,7 @@ func TestGenerateRFCMatrix(t *testing.T) {
 }
 
 func TestGenerateRFCMatrixWithSHA256(t *testing.T) {
-	for _, entry := range rfcTestMatrix {
+	for _, entry := range sha256TestMatrix {
 		code, err := GenerateCode(entry.Secret, entry.Counter,
 			&otp.OtpOptions{
 				CodeSize:  common.SixDigits,
@@ -61,7 +90,7 @@ func TestGenerateRFCMatrixWithSHA256(t *testing.T) {
 }
 
 func TestGenerateRFCMatrixWithSHA512(t *testing.T) {
-	for _, entry := range rfcTestMatrix {
+	for _, entry := range sha512TestMatrix {
 		code, err := GenerateCode(entry.Secret, entry.Counter,
 			&otp.OtpOptions{
 				CodeSize:  common.SixDigits,
@@ -99,7 +128,7 @@ func TestValidateRFCTestMatrix(t *testing.T) {
 
 func TestValidateRFCWithSHA256AlgorithmMatrix(t *testing.T) {
 
-	for _, entry := range rfcTestMatrix {
+	for _, entry := range sha256TestMatrix {
 		valid, err := Validate(entry.Code, entry.Counter, entry.Secret,
 			&otp.OtpOptions{
 				CodeSize:  common.SixDigits,
@@ -114,7 +143,7 @@ func TestValidateRFCWithSHA256AlgorithmMatrix(t *testing.T) {
 
 func TestValidateRFCWithSHA512AlgorithmMatrix(t *testing.T) {
 
-	for _, entry := range rfcTestMatrix {
+	for _, entry := range sha512TestMatrix {
 		valid, err := Validate(entry.Code, entry.Counter, entry.Secret,
 			&otp.OtpOptions{
 				CodeSize:  common.SixDigits,
@@ -206,7 +235,7 @@ func TestGenerateKey(t *testing.T) {
 		UserId: "foobar@example.com",
 	})
 
-	assert.Equal(t, common.ErrorEmptyIssuer, err)
+	assert.ErrorIs(t, err, common.ErrorEmptyIssuer)
 
 	// empty userid
 	_, err = GenerateKey(&otp.OtpKeyOptions{
@@ -214,7 +243,7 @@ func TestGenerateKey(t *testing.T) {
 		UserId: "",
 	})
 
-	assert.Equal(t, common.ErrorEmptyUserID, err)
+	assert.ErrorIs(t, err, common.ErrorEmptyUserID)
 
 	// big secrets
 	k, err = GenerateKey(&otp.OtpKeyOptions{
@@ -236,3 +265,23 @@ func TestGenerateKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, k.Secret(), "=")
 }
+
+func TestGenerateAndValidateNotifyOnce(t *testing.T) {
+	events := []otp.Event{}
+	otp.SetObserver(otp.ObserverFunc(func(_ context.Context, event otp.Event) {
+		events = append(events, event)
+	}))
+	t.Cleanup(func() { otp.SetObserver(nil) })
+
+	code, err := GenerateCode(sha1Secret, 0, nil)
+	require.NoError(t, err)
+	valid, err := Validate(code, 0, sha1Secret, nil)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, otp.GenerateEvent, events[0].Kind)
+	assert.Equal(t, "hotp", events[0].Type)
+	assert.Equal(t, otp.ValidateEvent, events[1].Kind)
+	assert.Equal(t, "hotp", events[1].Type)
+}
@@ -0,0 +1,110 @@
+package hotp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+const DefaultLookAhead = 10
+
+type Validator struct {
+	// LookAhead is how many counters past the expected one are tried to
+	// resynchronize a token that was pressed without logging in.
+	LookAhead uint
+	// Policy overrides otp.DefaultPolicy for the options and secrets checked
+	// by this validator.
+	Policy *otp.Policy
+	// Normalizer overrides otp.DefaultCodeNormalizer for the codes checked
+	// by this validator.
+	Normalizer *otp.CodeNormalizer
+	Logger     *slog.Logger
+	Observer   otp.Observer
+}
+
+func NewValidator() *Validator {
+	result := Validator{
+		LookAhead: DefaultLookAhead,
+	}
+
+	return &result
+}
+
+// Validate tries the expected counter and the look ahead window after it,
+// returning the counter that matched so the caller can store the next one.
+func (v *Validator) Validate(code string, secret string, counter uint64, options *otp.OtpOptions) (uint64, bool, error) {
+	return v.ValidateContext(context.Background(), code, secret, counter, options)
+}
+
+// ValidateContext works like Validate, it fails if ctx is done and reports to
+// the logger and observer carried by ctx when the validator has none.
+func (v *Validator) ValidateContext(ctx context.Context, code string, secret string, counter uint64, options *otp.OtpOptions) (uint64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return counter, false, err
+	}
+
+	if options == nil {
+		options = otp.NewDefaultOtpOptions()
+	}
+
+	start := time.Now()
+	matched, valid, err := v.validateWindow(code, secret, counter, options)
+	otp.Notify(ctx, v.Observer, otp.Event{
+		Kind:      otp.ValidateEvent,
+		Type:      "hotp",
+		Outcome:   otp.OutcomeOf(valid, err),
+		Algorithm: options.Algorithm.String(),
+		Drift:     int64(matched - counter),
+		Err:       err,
+	}, start)
+
+	switch {
+	case err != nil:
+		v.logger(ctx).Warn("hotp validation error", slog.Any("error", err))
+	case valid:
+		v.logger(ctx).Debug("hotp code accepted", slog.Uint64("counter", matched))
+	default:
+		v.logger(ctx).Debug("hotp code rejected", slog.Uint64("counter", counter))
+	}
+
+	return matched, valid, err
+}
+
+func (v *Validator) validateWindow(code string, secret string, counter uint64, options *otp.OtpOptions) (uint64, bool, error) {
+	policy := v.policy()
+	if err := errors.Join(policy.CheckOptions(options), policy.CheckSecret(secret)); err != nil {
+		return counter, false, err
+	}
+
+	generator, err := otp.NewGenerator(secret, options)
+	if err != nil {
+		return counter, false, err
+	}
+	generator.SetNormalizer(v.Normalizer)
+
+	offset, valid, err := generator.ValidateWindow(code, counter, 0, v.LookAhead)
+	if err != nil || !valid {
+		return counter, false, err
+	}
+
+	return counter + uint64(offset), true, nil
+}
+
+func (v *Validator) policy() *otp.Policy {
+	if v.Policy != nil {
+		return v.Policy
+	}
+
+	return otp.DefaultPolicy()
+}
+
+func (v *Validator) logger(ctx context.Context) *slog.Logger {
+	if v.Logger != nil {
+		return v.Logger
+	}
+
+	return otp.LoggerFromContext(ctx)
+}
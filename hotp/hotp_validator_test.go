@@ -0,0 +1,133 @@
+package hotp
+
+import (
+	"context"
+	"encoding/base32"
+	"math"
+	"testing"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorLookAhead(t *testing.T) {
+	events := []otp.Event{}
+	validator := NewValidator()
+	validator.LookAhead = 3
+	validator.Observer = otp.ObserverFunc(func(_ context.Context, event otp.Event) {
+		events = append(events, event)
+	})
+
+	counter, valid, err := validator.Validate(rfcTestMatrix[5].Code, sha1Secret, 2, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, uint64(5), counter)
+
+	counter, valid, err = validator.Validate(rfcTestMatrix[9].Code, sha1Secret, 2, nil)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, uint64(2), counter)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, otp.SuccessOutcome, events[0].Outcome)
+	assert.Equal(t, int64(3), events[0].Drift)
+	assert.Equal(t, "hotp", events[0].Type)
+	assert.Equal(t, otp.FailureOutcome, events[1].Outcome)
+}
+
+func TestValidatorDoesNotOverflowCounter(t *testing.T) {
+	validator := NewValidator()
+
+	_, valid, err := validator.Validate(rfcTestMatrix[0].Code, sha1Secret, math.MaxUint64-1, nil)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestValidatorError(t *testing.T) {
+	validator := NewValidator()
+
+	_, valid, err := validator.Validate("123", sha1Secret, 0, nil)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
+	assert.False(t, valid)
+}
+
+func TestValidatorEnforcesPolicy(t *testing.T) {
+	validator := NewValidator()
+	validator.Policy = &otp.Policy{MinSecretSize: 32}
+
+	_, valid, err := validator.Validate(rfcTestMatrix[0].Code, sha1Secret, 0, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.False(t, valid)
+
+	validator.Policy = &otp.Policy{Algorithms: []common.Algorithm{common.SHA256Algorithm}}
+	_, _, err = validator.Validate(rfcTestMatrix[0].Code, sha1Secret, 0, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+
+	_, valid, err = validator.Validate(sha256TestMatrix[0].Code, sha1Secret, 0, &otp.OtpOptions{CodeSize: common.SixDigits, Algorithm: common.SHA256Algorithm})
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidatorStrictPolicy(t *testing.T) {
+	validator := NewValidator()
+	validator.Policy = otp.StrictPolicy()
+
+	// the RFC 4226 secret is 20 bytes, above the 128 bit minimum
+	for _, matrix := range [][]RfcTestMatrixEntry{rfcTestMatrix, sha256TestMatrix, sha512TestMatrix} {
+		for _, entry := range matrix {
+			_, valid, err := validator.Validate(entry.Code, sha1Secret, entry.Counter, &otp.OtpOptions{CodeSize: common.SixDigits, Algorithm: entry.Mode})
+			require.NoError(t, err, entry.Mode)
+			assert.True(t, valid, entry.Mode)
+		}
+	}
+
+	_, _, err := validator.Validate(rfcTestMatrix[0].Code, otp.NewRandomOtpSecret(10).Value(), 0, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "secret")
+}
+
+func TestValidateEnforcesDefaultPolicy(t *testing.T) {
+	otp.SetPolicy(otp.StrictPolicy())
+	t.Cleanup(func() { otp.SetPolicy(nil) })
+
+	weak := otp.NewSecret(base32.StdEncoding.EncodeToString([]byte("1234567890"))).Value()
+	code, err := GenerateCode(weak, 0, nil)
+	require.NoError(t, err)
+
+	valid, err := Validate(code, 0, weak, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.False(t, valid)
+
+	valid, err = Validate(rfcTestMatrix[0].Code, 0, sha1Secret, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidatorNormalizer(t *testing.T) {
+	validator := NewValidator()
+
+	_, valid, err := validator.Validate("755 224", sha1Secret, 0, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	validator.Normalizer = &otp.CodeNormalizer{}
+	_, valid, err = validator.Validate("755 224", sha1Secret, 0, nil)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
+	assert.False(t, valid)
+}
+
+func TestValidatorContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	counter, valid, err := NewValidator().ValidateContext(ctx, rfcTestMatrix[2].Code, sha1Secret, 2, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, valid)
+	assert.Equal(t, uint64(2), counter)
+
+	valid, err = ValidateContext(ctx, rfcTestMatrix[2].Code, 2, sha1Secret, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, valid)
+}
@@ -0,0 +1,72 @@
+package expvarobserver
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+// Observer publishes the otp events as expvar counters under a single map,
+// e.g. "totp.validate.success", "totp.validate.latency_seconds" and
+// "totp.drift.-1".
+type Observer struct {
+	vars *expvar.Map
+}
+
+// publishMu serializes New so two observers with the same name share the
+// map instead of both publishing it.
+var publishMu sync.Mutex
+
+// New returns an observer publishing its counters under name, the map is
+// reused when name was already published as one and an error is returned
+// when it was published as another type of variable.
+func New(name string) (*Observer, error) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	var vars *expvar.Map
+	switch published := expvar.Get(name).(type) {
+	case nil:
+		vars = expvar.NewMap(name)
+	case *expvar.Map:
+		vars = published
+	default:
+		return nil, fmt.Errorf("expvar %q is already published as a %T", name, published)
+	}
+
+	result := Observer{
+		vars: vars,
+	}
+
+	return &result, nil
+}
+
+func (o *Observer) Observe(_ context.Context, event otp.Event) {
+	prefix := key(event.Type, string(event.Kind))
+
+	o.vars.Add(key(prefix, string(event.Outcome)), 1)
+	o.vars.AddFloat(key(prefix, "latency_seconds"), event.Latency.Seconds())
+
+	if event.Kind == otp.ValidateEvent && event.Outcome == otp.SuccessOutcome {
+		o.vars.Add(key(event.Type, "drift", fmt.Sprintf("%d", event.Drift)), 1)
+	}
+}
+
+func (o *Observer) Vars() *expvar.Map {
+	return o.vars
+}
+
+func key(parts ...string) string {
+	result := []string{}
+	for _, part := range parts {
+		if part != "" {
+			result = append(result, part)
+		}
+	}
+
+	return strings.Join(result, ".")
+}
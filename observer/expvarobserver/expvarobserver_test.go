@@ -0,0 +1,74 @@
+package expvarobserver
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserve(t *testing.T) {
+	observer, err := New("otp_test_observe")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	observer.Observe(ctx, otp.Event{Kind: otp.ValidateEvent, Type: "totp", Outcome: otp.SuccessOutcome, Drift: -1, Latency: time.Second})
+	observer.Observe(ctx, otp.Event{Kind: otp.ValidateEvent, Type: "totp", Outcome: otp.SuccessOutcome, Drift: -1, Latency: time.Second})
+	observer.Observe(ctx, otp.Event{Kind: otp.ValidateEvent, Type: "totp", Outcome: otp.FailureOutcome})
+	observer.Observe(ctx, otp.Event{Kind: otp.LockoutEvent, Outcome: otp.LockedOutOutcome})
+
+	vars := observer.Vars()
+	assert.Equal(t, "2", vars.Get("totp.validate.success").String())
+	assert.Equal(t, "1", vars.Get("totp.validate.failure").String())
+	assert.Equal(t, "2", vars.Get("totp.validate.latency_seconds").String())
+	assert.Equal(t, "2", vars.Get("totp.drift.-1").String())
+	assert.Equal(t, "1", vars.Get("lockout.locked_out").String())
+}
+
+func TestNewReusesPublishedMap(t *testing.T) {
+	first, err := New("otp_test_reuse")
+	require.NoError(t, err)
+	second, err := New("otp_test_reuse")
+	require.NoError(t, err)
+
+	assert.Same(t, first.Vars(), second.Vars())
+	assert.Same(t, first.Vars(), expvar.Get("otp_test_reuse"))
+}
+
+func TestNewPublishedAsOtherType(t *testing.T) {
+	expvar.NewInt("otp_test_int")
+
+	observer, err := New("otp_test_int")
+	assert.Error(t, err)
+	assert.Nil(t, observer)
+}
+
+func TestObserveTotpValidation(t *testing.T) {
+	observer, err := New("otp_test_totp")
+	require.NoError(t, err)
+	otp.SetObserver(observer)
+	t.Cleanup(func() {
+		otp.SetObserver(nil)
+	})
+
+	secret := "JBSWY3DPEHPK3PXP"
+	now := time.Now().UTC()
+	code, err := totp.GenerateCode(secret, now, nil)
+	require.NoError(t, err)
+
+	valid, err := totp.Validate(code, secret, now, nil)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	vars := observer.Vars()
+	assert.Equal(t, "1", vars.Get("totp.generate.success").String())
+	assert.Equal(t, "1", vars.Get("totp.validate.success").String())
+	assert.Equal(t, "1", vars.Get("totp.drift.0").String())
+	// the otp functions the totp ones use are not reported again
+	assert.Nil(t, vars.Get("otp.generate.success"))
+}
@@ -0,0 +1,88 @@
+package otelobserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Observer records the otp events as OpenTelemetry metrics and spans, either
+// the meter or the tracer can be nil to only record one of them.
+type Observer struct {
+	tracer  trace.Tracer
+	events  metric.Int64Counter
+	latency metric.Float64Histogram
+	drift   metric.Int64Histogram
+}
+
+func New(meter metric.Meter, tracer trace.Tracer) (*Observer, error) {
+	result := Observer{
+		tracer: tracer,
+	}
+
+	if meter == nil {
+		return &result, nil
+	}
+
+	var err error
+	result.events, err = meter.Int64Counter("otp.events",
+		metric.WithDescription("Number of otp generate, validate, enroll and lockout events"))
+	if err != nil {
+		return nil, err
+	}
+
+	result.latency, err = meter.Float64Histogram("otp.latency",
+		metric.WithDescription("Duration of the otp operations"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	result.drift, err = meter.Int64Histogram("otp.drift",
+		metric.WithDescription("Offset of the matching step or counter on successful validations"),
+		metric.WithUnit("{step}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (o *Observer) Observe(ctx context.Context, event otp.Event) {
+	attributes := []attribute.KeyValue{
+		attribute.String("otp.kind", string(event.Kind)),
+		attribute.String("otp.type", event.Type),
+		attribute.String("otp.outcome", string(event.Outcome)),
+		attribute.String("otp.algorithm", event.Algorithm),
+	}
+
+	if o.events != nil {
+		options := metric.WithAttributes(attributes...)
+		o.events.Add(ctx, 1, options)
+		o.latency.Record(ctx, event.Latency.Seconds(), options)
+
+		if event.Kind == otp.ValidateEvent && event.Outcome == otp.SuccessOutcome {
+			o.drift.Record(ctx, event.Drift, options)
+		}
+	}
+
+	if o.tracer != nil {
+		end := time.Now()
+		_, span := o.tracer.Start(ctx, "otp."+string(event.Kind),
+			trace.WithTimestamp(end.Add(-event.Latency)),
+			trace.WithAttributes(attributes...),
+			trace.WithAttributes(attribute.Int64("otp.drift", event.Drift)))
+
+		if event.Err != nil {
+			span.RecordError(event.Err)
+			span.SetStatus(codes.Error, event.Err.Error())
+		}
+
+		span.End(trace.WithTimestamp(end))
+	}
+}
@@ -0,0 +1,178 @@
+package otelobserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordedValue struct {
+	name  string
+	value float64
+	attrs attribute.Set
+}
+
+type recordingMeter struct {
+	metricnoop.Meter
+	values *[]recordedValue
+}
+
+func (m recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return recordingInt64Counter{name: name, values: m.values}, nil
+}
+
+func (m recordingMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return recordingFloat64Histogram{name: name, values: m.values}, nil
+}
+
+func (m recordingMeter) Int64Histogram(name string, _ ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	return recordingInt64Histogram{name: name, values: m.values}, nil
+}
+
+type recordingInt64Counter struct {
+	metricnoop.Int64Counter
+	name   string
+	values *[]recordedValue
+}
+
+func (c recordingInt64Counter) Add(_ context.Context, value int64, options ...metric.AddOption) {
+	attrs := metric.NewAddConfig(options).Attributes()
+	*c.values = append(*c.values, recordedValue{c.name, float64(value), attrs})
+}
+
+type recordingFloat64Histogram struct {
+	metricnoop.Float64Histogram
+	name   string
+	values *[]recordedValue
+}
+
+func (h recordingFloat64Histogram) Record(_ context.Context, value float64, options ...metric.RecordOption) {
+	attrs := metric.NewRecordConfig(options).Attributes()
+	*h.values = append(*h.values, recordedValue{h.name, value, attrs})
+}
+
+type recordingInt64Histogram struct {
+	metricnoop.Int64Histogram
+	name   string
+	values *[]recordedValue
+}
+
+func (h recordingInt64Histogram) Record(_ context.Context, value int64, options ...metric.RecordOption) {
+	attrs := metric.NewRecordConfig(options).Attributes()
+	*h.values = append(*h.values, recordedValue{h.name, float64(value), attrs})
+}
+
+type recordingSpan struct {
+	tracenoop.Span
+	name   string
+	start  time.Time
+	end    *time.Time
+	status *codes.Code
+	errs   *[]error
+}
+
+func (s recordingSpan) End(options ...trace.SpanEndOption) {
+	config := trace.NewSpanEndConfig(options...)
+	*s.end = config.Timestamp()
+}
+
+func (s recordingSpan) SetStatus(code codes.Code, _ string) {
+	*s.status = code
+}
+
+func (s recordingSpan) RecordError(err error, _ ...trace.EventOption) {
+	*s.errs = append(*s.errs, err)
+}
+
+type recordingTracer struct {
+	tracenoop.Tracer
+	spans *[]recordingSpan
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(options...)
+	span := recordingSpan{
+		name:   name,
+		start:  config.Timestamp(),
+		end:    &time.Time{},
+		status: new(codes.Code),
+		errs:   &[]error{},
+	}
+	*t.spans = append(*t.spans, span)
+
+	return ctx, span
+}
+
+func TestObserveRecordsMetrics(t *testing.T) {
+	values := []recordedValue{}
+	observer, err := New(recordingMeter{values: &values}, nil)
+	require.NoError(t, err)
+
+	observer.Observe(context.Background(), otp.Event{
+		Kind:      otp.ValidateEvent,
+		Type:      "totp",
+		Outcome:   otp.SuccessOutcome,
+		Algorithm: "SHA1",
+		Drift:     -1,
+		Latency:   2 * time.Millisecond,
+	})
+	observer.Observe(context.Background(), otp.Event{
+		Kind:    otp.LockoutEvent,
+		Outcome: otp.LockedOutOutcome,
+	})
+
+	require.Len(t, values, 5)
+	assert.Equal(t, "otp.events", values[0].name)
+	assert.Equal(t, float64(1), values[0].value)
+	outcome, ok := values[0].attrs.Value("otp.outcome")
+	assert.True(t, ok)
+	assert.Equal(t, "success", outcome.AsString())
+	assert.Equal(t, "otp.latency", values[1].name)
+	assert.Equal(t, 0.002, values[1].value)
+	assert.Equal(t, "otp.drift", values[2].name)
+	assert.Equal(t, float64(-1), values[2].value)
+	kind, _ := values[3].attrs.Value("otp.kind")
+	assert.Equal(t, "lockout", kind.AsString())
+	outcome, _ = values[3].attrs.Value("otp.outcome")
+	assert.Equal(t, "locked_out", outcome.AsString())
+}
+
+func TestObserveRecordsSpans(t *testing.T) {
+	spans := []recordingSpan{}
+	observer, err := New(nil, recordingTracer{spans: &spans})
+	require.NoError(t, err)
+
+	observer.Observe(context.Background(), otp.Event{
+		Kind:    otp.GenerateEvent,
+		Type:    "hotp",
+		Outcome: otp.ErrorOutcome,
+		Latency: time.Second,
+		Err:     errors.New("boom"),
+	})
+
+	require.Len(t, spans, 1)
+	assert.Equal(t, "otp.generate", spans[0].name)
+	assert.Equal(t, time.Second, spans[0].end.Sub(spans[0].start))
+	assert.Equal(t, codes.Error, *spans[0].status)
+	assert.Len(t, *spans[0].errs, 1)
+}
+
+func TestNewWithNoopProviders(t *testing.T) {
+	observer, err := New(metricnoop.NewMeterProvider().Meter("otp"), tracenoop.NewTracerProvider().Tracer("otp"))
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		observer.Observe(context.Background(), otp.Event{Kind: otp.EnrollEvent, Outcome: otp.SuccessOutcome})
+	})
+}
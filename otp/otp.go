@@ -1,105 +1,167 @@
 package otp
 
 import (
-	"crypto/hmac"
+	"context"
 	"crypto/subtle"
-	"encoding/base32"
-	"encoding/binary"
+	"errors"
 	"fmt"
-	"math"
+	"log/slog"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/cjlapao/common-go-identity-otp/common"
 	"github.com/cjlapao/common-go-identity-otp/helpers"
 	"github.com/cjlapao/common-go/guard"
 )
 
-var debug = false
-
-func ToggleDebug() {
-	debug = !debug
+func GenerateCode(secret string, counter uint64, options *OtpOptions) (string, error) {
+	return GenerateCodeContext(context.Background(), secret, counter, options)
 }
 
-func GenerateCode(secret string, counter uint64, options *OtpOptions) (string, error) {
+// GenerateCodeContext works like GenerateCode, it fails if ctx is done and
+// reports to the logger and observer carried by ctx.
+func GenerateCodeContext(ctx context.Context, secret string, counter uint64, options *OtpOptions) (string, error) {
 	if options == nil {
 		options = NewDefaultOtpOptions()
 	}
 
+	start := time.Now()
+	code, err := generateCode(ctx, secret, counter, options)
+	Notify(ctx, nil, Event{
+		Kind:      GenerateEvent,
+		Type:      "otp",
+		Outcome:   OutcomeOf(err == nil, err),
+		Algorithm: options.Algorithm.String(),
+		Err:       err,
+	}, start)
+
+	return code, err
+}
+
+// generateCode does not notify the observer, ValidateCodeContext uses it so
+// a validation is reported as a single event.
+func generateCode(ctx context.Context, secret string, counter uint64, options *OtpOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	if options.CodeSize == 0 {
 		options.CodeSize = common.SixDigits
 	}
 
-	secret = helpers.PadSecret(secret)
-
-	secretBytes, err := base32.StdEncoding.DecodeString(secret)
+	generator, err := NewGenerator(secret, options)
 	if err != nil {
-		return "", common.ErrorInvalidSecret
+		return "", err
 	}
 
-	buff := make([]byte, common.MODULUS_SIZE)
-	mac := hmac.New(options.Algorithm.Hash, secretBytes)
-	binary.BigEndian.PutUint64(buff, counter)
-
-	if debug {
-		fmt.Printf("counter=%v\n", counter)
-		fmt.Printf("buf=%v\n", buff)
-	}
+	code := generator.Generate(counter)
 
-	mac.Write(buff)
-	sum := mac.Sum(nil)
+	LoggerFromContext(ctx).Debug("otp code generated",
+		slog.Uint64("counter", counter),
+		slog.String("algorithm", options.Algorithm.String()),
+		slog.Int("digits", options.CodeSize.Length()))
 
-	offset := sum[len(sum)-1] & 0xf
-	value := int64(((int(sum[offset]) & 0x7f) << 24) |
-		((int(sum[offset+1] & 0xff)) << 16) |
-		((int(sum[offset+2] & 0xff)) << 8) |
-		(int(sum[offset+3]) & 0xff))
+	return code, nil
+}
 
-	l := options.CodeSize.Length()
-	mod := uint64(value % int64(math.Pow10(l)))
+func ValidateCode(code string, counter uint64, secret string, options *OtpOptions) (bool, error) {
+	return ValidateCodeContext(context.Background(), code, counter, secret, options)
+}
 
-	if debug {
-		fmt.Printf("offset=%v\n", offset)
-		fmt.Printf("value=%v\n", value)
-		fmt.Printf("mod'ed=%v\n", mod)
+// ValidateCodeContext works like ValidateCode, it fails if ctx is done and
+// reports to the logger and observer carried by ctx. The options and secret
+// are checked against DefaultPolicy.
+func ValidateCodeContext(ctx context.Context, code string, counter uint64, secret string, options *OtpOptions) (bool, error) {
+	if options == nil {
+		options = NewDefaultOtpOptions()
 	}
 
-	return options.CodeSize.Format(mod), nil
+	start := time.Now()
+	valid, err := validateCode(ctx, code, counter, secret, options)
+	Notify(ctx, nil, Event{
+		Kind:      ValidateEvent,
+		Type:      "otp",
+		Outcome:   OutcomeOf(valid, err),
+		Algorithm: options.Algorithm.String(),
+		Err:       err,
+	}, start)
+
+	return valid, err
 }
 
-func ValidateCode(code string, counter uint64, secret string, options *OtpOptions) (bool, error) {
-	code = strings.TrimSpace(code)
-	if options == nil {
-		options = NewDefaultOtpOptions()
+func validateCode(ctx context.Context, code string, counter uint64, secret string, options *OtpOptions) (bool, error) {
+	code = NormalizeCode(code)
+	if options.CodeSize == 0 {
+		options.CodeSize = common.SixDigits
+	}
+
+	if err := options.CodeSize.Validate(); err != nil {
+		return false, common.WithField(err, "CodeSize")
+	}
+
+	policy := DefaultPolicy()
+	if err := errors.Join(policy.CheckOptions(options), policy.CheckSecret(secret)); err != nil {
+		return false, err
 	}
 
 	if len(code) != options.CodeSize.Length() {
-		return false, common.ErrorWrongCodeSize
+		return false, common.ErrorWrongCodeSize.WithField("code")
 	}
 
-	genCode, err := GenerateCode(secret, counter, options)
+	genCode, err := generateCode(ctx, secret, counter, options)
 	if err != nil {
 		return false, err
 	}
 
-	if subtle.ConstantTimeCompare([]byte(code), []byte(genCode)) == 1 {
-		return true, nil
-	}
+	valid := subtle.ConstantTimeCompare([]byte(code), []byte(genCode)) == 1
+	LoggerFromContext(ctx).Debug("otp code validated",
+		slog.Uint64("counter", counter),
+		slog.String("algorithm", options.Algorithm.String()),
+		slog.Bool("valid", valid))
 
-	return false, nil
+	return valid, nil
 }
 
 func GenerateKey(algorithm string, opts *OtpKeyOptions) (*OtpKey, error) {
+	return GenerateKeyContext(context.Background(), algorithm, opts)
+}
+
+// GenerateKeyContext works like GenerateKey, it fails if ctx is done and
+// notifies the observer carried by ctx.
+func GenerateKeyContext(ctx context.Context, algorithm string, opts *OtpKeyOptions) (*OtpKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	key, err := generateKey(algorithm, opts)
+
+	event := Event{
+		Kind:    EnrollEvent,
+		Type:    strings.ToLower(algorithm),
+		Outcome: OutcomeOf(err == nil, err),
+		Err:     err,
+	}
+	if opts != nil && opts.Options != nil {
+		event.Algorithm = opts.Options.Algorithm.String()
+	}
+	Notify(ctx, nil, event, start)
+
+	return key, err
+}
+
+func generateKey(algorithm string, opts *OtpKeyOptions) (*OtpKey, error) {
 	if opts == nil {
-		return nil, common.ErrorNilOtpKeyOptions
+		return nil, common.ErrorNilOtpKeyOptions.WithField("opts")
 	}
 
-	if err := guard.EmptyOrNil(opts.Issuer); err != nil {
-		return nil, common.ErrorEmptyIssuer
+	if err := guard.EmptyOrNil(opts.Issuer, "Issuer"); err != nil {
+		return nil, common.ErrorEmptyIssuer.WithField("Issuer").WithCause(err)
 	}
 
-	if err := guard.EmptyOrNil(opts.UserId); err != nil {
-		return nil, common.ErrorEmptyUserID
+	if err := guard.EmptyOrNil(opts.UserId, "UserId"); err != nil {
+		return nil, common.ErrorEmptyUserID.WithField("UserId").WithCause(err)
 	}
 
 	if opts.Secret == nil {
@@ -110,6 +172,27 @@ func GenerateKey(algorithm string, opts *OtpKeyOptions) (*OtpKey, error) {
 		opts.Options = NewDefaultOtpOptions()
 	}
 
+	if opts.Options.CodeSize == 0 {
+		opts.Options.CodeSize = common.SixDigits
+	}
+
+	if err := opts.Options.CodeSize.Validate(); err != nil {
+		return nil, common.WithField(err, "Options.CodeSize")
+	}
+
+	if err := opts.Options.Algorithm.Validate(); err != nil {
+		return nil, common.WithField(err, "Options.Algorithm")
+	}
+
+	policy := opts.Policy
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+
+	if err := errors.Join(policy.CheckSecret(opts.Secret.Value()), policy.CheckOptions(opts.Options)); err != nil {
+		return nil, err
+	}
+
 	keyUrl := url.Values{}
 	keyUrl.Set("secret", opts.Secret.Value())
 	keyUrl.Set("issuer", opts.Issuer)
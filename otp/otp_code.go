@@ -0,0 +1,154 @@
+package otp
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// DefaultCodeSeparators are the characters users type or paste between
+// groups of digits, white space is handled by CodeNormalizer.Spaces.
+const DefaultCodeSeparators = "-.\u2010\u2011\u2012\u2013\u2014\u2212"
+
+// CodeNormalizer turns a code as typed or pasted by a user into the ASCII
+// digits it is validated as, white space around the code is always removed.
+type CodeNormalizer struct {
+	// Spaces removes white space anywhere in the code, e.g. "123 456".
+	Spaces bool
+	// Separators are other characters removed from the code.
+	Separators string
+	// Digits maps the Unicode decimal digits, e.g. full width or
+	// Arabic-Indic ones from mobile keyboards, to ASCII.
+	Digits bool
+}
+
+func NewDefaultCodeNormalizer() *CodeNormalizer {
+	result := CodeNormalizer{
+		Spaces:     true,
+		Separators: DefaultCodeSeparators,
+		Digits:     true,
+	}
+
+	return &result
+}
+
+type codeNormalizerHolder struct {
+	normalizer *CodeNormalizer
+}
+
+var defaultCodeNormalizer atomic.Pointer[codeNormalizerHolder]
+
+func init() {
+	SetCodeNormalizer(NewDefaultCodeNormalizer())
+}
+
+// SetCodeNormalizer sets the normalizer applied to the codes before they are
+// validated by the validators, generators and services that were not given
+// one, nil only trims white space around the code.
+func SetCodeNormalizer(normalizer *CodeNormalizer) {
+	if normalizer == nil {
+		normalizer = &CodeNormalizer{}
+	}
+
+	defaultCodeNormalizer.Store(&codeNormalizerHolder{normalizer: normalizer})
+}
+
+func DefaultCodeNormalizer() *CodeNormalizer {
+	return defaultCodeNormalizer.Load().normalizer
+}
+
+// NormalizeCode normalizes the code with the default normalizer.
+func NormalizeCode(code string) string {
+	return DefaultCodeNormalizer().Normalize(code)
+}
+
+// Normalize applies the normalizer to the code, a nil normalizer uses the
+// default one so validators can leave their normalizer unset.
+func (n *CodeNormalizer) Normalize(code string) string {
+	if n == nil {
+		n = DefaultCodeNormalizer()
+	}
+
+	if isASCIIDigits(code) {
+		return code
+	}
+
+	code = strings.TrimSpace(code)
+
+	var result strings.Builder
+	result.Grow(len(code))
+	for _, r := range code {
+		switch {
+		case n.Spaces && unicode.IsSpace(r):
+		case strings.ContainsRune(n.Separators, r):
+		case n.Digits && r > unicode.MaxASCII && unicode.Is(unicode.Nd, r):
+			result.WriteByte('0' + digitValue(r))
+		default:
+			result.WriteRune(r)
+		}
+	}
+
+	return result.String()
+}
+
+// DisplayCode groups the digits of a code for presentation, codes of up to
+// four digits are kept whole, lengths divisible by three are split in groups
+// of three and the others in two halves, e.g. "123 456" or "1234 5678".
+func DisplayCode(code string) string {
+	switch {
+	case len(code) <= 4:
+		return code
+	case len(code)%3 == 0:
+		return FormatCode(code, 3, " ")
+	default:
+		half := len(code) / 2
+		return code[:half] + " " + code[half:]
+	}
+}
+
+// FormatCode splits the code in groups of groupSize digits, from the left,
+// joined by the separator.
+func FormatCode(code string, groupSize int, separator string) string {
+	if groupSize <= 0 || len(code) <= groupSize {
+		return code
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(code); i += groupSize {
+		if i > 0 {
+			result.WriteString(separator)
+		}
+		result.WriteString(code[i:min(i+groupSize, len(code))])
+	}
+
+	return result.String()
+}
+
+func isASCIIDigits(code string) bool {
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+
+	return code != ""
+}
+
+// digitValue relies on every Unicode decimal digit block being a run of ten
+// code points starting at zero, so a range of the Nd table always starts at
+// a zero digit.
+func digitValue(r rune) byte {
+	for _, digits := range unicode.Nd.R16 {
+		if rune(digits.Lo) <= r && r <= rune(digits.Hi) {
+			return byte((r - rune(digits.Lo)) % 10)
+		}
+	}
+
+	for _, digits := range unicode.Nd.R32 {
+		if rune(digits.Lo) <= r && r <= rune(digits.Hi) {
+			return byte((r - rune(digits.Lo)) % 10)
+		}
+	}
+
+	return 0
+}
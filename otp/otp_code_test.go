@@ -0,0 +1,106 @@
+package otp
+
+import (
+	"testing"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCode(t *testing.T) {
+	for input, expected := range map[string]string{
+		"755224":                                "755224",
+		" 755224\n":                             "755224",
+		"755 224":                               "755224",
+		"755-224":                               "755224",
+		"7.5.5.2.2.4":                           "755224",
+		"755\u00a0224":                          "755224",
+		"\u3000755224\u2009":                    "755224",
+		"755\u2013224":                          "755224",
+		"\uff17\uff15\uff15\uff12\uff12\uff14":  "755224",
+		"\u0667\u0665\u0665\u0662\u0662\u0664":  "755224",
+		"\u096d\u096b\u096b \u0968\u0968\u096a": "755224",
+		"\U0001d7d5\U0001d7d3\U0001d7d3\U0001d7d0\U0001d7d0\U0001d7d2": "755224",
+		"75a224": "75a224",
+		"":       "",
+	} {
+		assert.Equal(t, expected, NormalizeCode(input), input)
+	}
+}
+
+func TestCodeNormalizerOptions(t *testing.T) {
+	trimOnly := &CodeNormalizer{}
+	assert.Equal(t, "755 224", trimOnly.Normalize(" 755 224 "))
+	assert.Equal(t, "\uff17\uff15\uff15", trimOnly.Normalize("\uff17\uff15\uff15"))
+
+	custom := &CodeNormalizer{Separators: "/"}
+	assert.Equal(t, "755224", custom.Normalize("755/224"))
+	assert.Equal(t, "755-224", custom.Normalize("755-224"))
+}
+
+func TestValidateNormalizedCode(t *testing.T) {
+	entry := rfcTestMatrix[0]
+
+	for _, code := range []string{"755 224", "755-224", "\uff17\uff15\uff15\uff12\uff12\uff14", "\u0667\u0665\u0665\u0662\u0662\u0664"} {
+		valid, err := ValidateCode(code, entry.Counter, entry.Secret, nil)
+		require.NoError(t, err, code)
+		assert.True(t, valid, code)
+	}
+
+	generator, err := NewGenerator(entry.Secret, nil)
+	require.NoError(t, err)
+	valid, err := generator.Validate("755 224", entry.Counter)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	SetCodeNormalizer(nil)
+	t.Cleanup(func() {
+		SetCodeNormalizer(NewDefaultCodeNormalizer())
+	})
+
+	_, err = ValidateCode("755 224", entry.Counter, entry.Secret, nil)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
+
+	valid, err = ValidateCode(" 755224 ", entry.Counter, entry.Secret, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestDisplayCode(t *testing.T) {
+	for code, expected := range map[string]string{
+		"1234":       "1234",
+		"12345":      "12 345",
+		"123456":     "123 456",
+		"1234567":    "123 4567",
+		"12345678":   "1234 5678",
+		"123456789":  "123 456 789",
+		"1234567890": "12345 67890",
+	} {
+		assert.Equal(t, expected, DisplayCode(code), code)
+		assert.Equal(t, code, NormalizeCode(DisplayCode(code)), code)
+	}
+
+	assert.Equal(t, "12-34-56-78", FormatCode("12345678", 2, "-"))
+	assert.Equal(t, "1234-5678-9", FormatCode("123456789", 4, "-"))
+	assert.Equal(t, "123456", FormatCode("123456", 0, "-"))
+	assert.Equal(t, "123", FormatCode("123", 3, "-"))
+}
+
+func TestGeneratorNormalizer(t *testing.T) {
+	entry := rfcTestMatrix[0]
+	generator, err := NewGenerator(entry.Secret, nil)
+	require.NoError(t, err)
+
+	generator.SetNormalizer(&CodeNormalizer{})
+	_, err = generator.Validate("755-224", entry.Counter)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
+
+	generator.SetNormalizer(&CodeNormalizer{Separators: "/"})
+	valid, err := generator.Validate("755/224", entry.Counter)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	var normalizer *CodeNormalizer
+	assert.Equal(t, "755224", normalizer.Normalize("755-224"))
+}
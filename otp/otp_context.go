@@ -0,0 +1,43 @@
+package otp
+
+import (
+	"context"
+	"log/slog"
+)
+
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	observerContextKey
+)
+
+// ContextWithLogger returns a copy of ctx carrying a request scoped logger,
+// the functions taking a context use it when no logger was set on them.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// LoggerFromContext returns the logger carried by ctx, or the package logger.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+
+	return Logger()
+}
+
+// ContextWithObserver returns a copy of ctx carrying a request scoped
+// observer, e.g. one recording spans under the request trace.
+func ContextWithObserver(ctx context.Context, observer Observer) context.Context {
+	return context.WithValue(ctx, observerContextKey, observer)
+}
+
+// ObserverFromContext returns the observer carried by ctx, or the default one.
+func ObserverFromContext(ctx context.Context) Observer {
+	if observer, ok := ctx.Value(observerContextKey).(Observer); ok && observer != nil {
+		return observer
+	}
+
+	return DefaultObserver()
+}
@@ -0,0 +1,62 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextLogger(t *testing.T) {
+	global := captureLogs(t)
+	buffer := &bytes.Buffer{}
+	ctx := ContextWithLogger(context.Background(), slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})).With(slog.String("request_id", "r-1")))
+
+	entry := rfcTestMatrix[0]
+	valid, err := ValidateCodeContext(ctx, entry.Code, entry.Counter, entry.Secret, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	assert.Contains(t, buffer.String(), `"request_id":"r-1"`)
+	assert.Contains(t, buffer.String(), "otp code validated")
+	assert.Empty(t, global.String())
+
+	assert.Equal(t, Logger(), LoggerFromContext(context.Background()))
+	assert.Equal(t, Logger(), LoggerFromContext(ContextWithLogger(context.Background(), nil)))
+}
+
+func TestContextObserver(t *testing.T) {
+	global := captureEvents(t)
+	var events []Event
+	ctx := ContextWithObserver(context.Background(), ObserverFunc(func(_ context.Context, event Event) {
+		events = append(events, event)
+	}))
+
+	_, err := GenerateKeyContext(ctx, "totp", NewDefaultOtpKeyOptions("foobar", "foobar@example.com"))
+	require.NoError(t, err)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, EnrollEvent, events[0].Kind)
+	assert.Empty(t, *global)
+}
+
+func TestCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	entry := rfcTestMatrix[0]
+
+	_, err := GenerateCodeContext(ctx, entry.Secret, entry.Counter, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	valid, err := ValidateCodeContext(ctx, entry.Code, entry.Counter, entry.Secret, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, valid)
+
+	_, err = GenerateKeyContext(ctx, "totp", NewDefaultOtpKeyOptions("foobar", "foobar@example.com"))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,199 @@
+package otp
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+// Options without a binding keep the version 1 layout, version 2 prefixes
+// the algorithm with its length and appends the binding.
+const (
+	optionsBinaryVersion      byte = 1
+	boundOptionsBinaryVersion byte = 2
+)
+
+// ParseKey parses an otpauth:// uri, unlike NewKeyFromUrl the type, label,
+// secret, digits and algorithm are all validated.
+func ParseKey(raw string) (*OtpKey, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, common.ErrorInvalidKey.WithCause(err)
+	}
+
+	if u.Scheme != "otpauth" {
+		return nil, common.ErrorInvalidKey.WithField("scheme")
+	}
+
+	key := OtpKey{
+		raw: raw,
+		url: u,
+	}
+
+	switch key.Type() {
+	case "totp", "hotp":
+	default:
+		return nil, common.ErrorUnsupportedKeyType.WithField("type")
+	}
+
+	if strings.TrimPrefix(u.Path, "/") == "" {
+		return nil, common.ErrorInvalidKey.WithField("label")
+	}
+
+	if err := validateSecret(key.Secret()); err != nil {
+		return nil, err
+	}
+
+	if _, err := key.Options(); err != nil {
+		return nil, err
+	}
+
+	if _, err := key.Period(); err != nil {
+		return nil, err
+	}
+
+	if _, err := key.Counter(); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func validateSecret(secret string) error {
+	if secret == "" {
+		return common.ErrorInvalidSecret.WithField("secret")
+	}
+
+	_, err := decodeSecret(secret)
+
+	return err
+}
+
+// MarshalText encodes the key as its full otpauth uri, the secret included.
+func (k *OtpKey) MarshalText() ([]byte, error) {
+	if k == nil || k.url == nil {
+		return nil, common.ErrorNilOtpKey
+	}
+
+	return []byte(k.raw), nil
+}
+
+func (k *OtpKey) UnmarshalText(text []byte) error {
+	key, err := ParseKey(string(text))
+	if err != nil {
+		return err
+	}
+
+	*k = *key
+
+	return nil
+}
+
+func (k *OtpKey) MarshalBinary() ([]byte, error) {
+	return k.MarshalText()
+}
+
+func (k *OtpKey) UnmarshalBinary(data []byte) error {
+	return k.UnmarshalText(data)
+}
+
+// MarshalText encodes the secret value, it is meant for storage and must
+// not end up in logs.
+func (s *OtpSecret) MarshalText() ([]byte, error) {
+	return []byte(s.value), nil
+}
+
+func (s *OtpSecret) UnmarshalText(text []byte) error {
+	if err := validateSecret(string(text)); err != nil {
+		return err
+	}
+
+	*s = *NewSecret(string(text))
+
+	return nil
+}
+
+// MarshalJSON encodes a zero CodeSize as the six digits default.
+func (o OtpOptions) MarshalJSON() ([]byte, error) {
+	type plain OtpOptions
+	options := o.withDefaults()
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(plain(options))
+}
+
+// UnmarshalJSON rejects unknown fields and invalid values, missing fields
+// keep their defaults.
+func (o *OtpOptions) UnmarshalJSON(data []byte) error {
+	type plain OtpOptions
+	result := plain(*NewDefaultOtpOptions())
+
+	if err := common.DecodeJSONStrict(data, &result); err != nil {
+		return err
+	}
+
+	options := OtpOptions(result)
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	*o = options
+
+	return nil
+}
+
+func (o *OtpOptions) MarshalBinary() ([]byte, error) {
+	options := o.withDefaults()
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	algorithm, err := options.Algorithm.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Binding == "" {
+		result := []byte{optionsBinaryVersion, byte(options.CodeSize)}
+
+		return append(result, algorithm...), nil
+	}
+
+	result := []byte{boundOptionsBinaryVersion, byte(options.CodeSize), byte(len(algorithm))}
+	result = append(result, algorithm...)
+
+	return append(result, options.Binding...), nil
+}
+
+func (o *OtpOptions) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 || (data[0] != optionsBinaryVersion && data[0] != boundOptionsBinaryVersion) {
+		return common.ErrorInvalidEncoding.WithField("OtpOptions")
+	}
+
+	var result OtpOptions
+	if err := result.CodeSize.UnmarshalBinary(data[1:2]); err != nil {
+		return common.WithField(err, "CodeSize")
+	}
+
+	algorithm := data[2:]
+	if data[0] == boundOptionsBinaryVersion {
+		size := int(data[2])
+		if size == 0 || len(data) <= 3+size {
+			return common.ErrorInvalidEncoding.WithField("Binding")
+		}
+		algorithm = data[3 : 3+size]
+		result.Binding = string(data[3+size:])
+	}
+
+	if err := result.Algorithm.UnmarshalBinary(algorithm); err != nil {
+		return common.WithField(err, "Algorithm")
+	}
+
+	*o = result
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package otp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	key := newRotationTestKey(t)
+
+	parsed, err := ParseKey(key.String())
+	require.NoError(t, err)
+	assert.Equal(t, key.String(), parsed.String())
+	assert.Equal(t, "foobar@example.com", parsed.UserId())
+
+	tests := []struct {
+		name string
+		uri  string
+		err  error
+	}{
+		{"scheme", "https://totp/foo:bar?secret=GEZDGNBV", common.ErrorInvalidKey},
+		{"type", "otpauth://motp/foo:bar?secret=GEZDGNBV", common.ErrorUnsupportedKeyType},
+		{"label", "otpauth://totp/?secret=GEZDGNBV", common.ErrorInvalidKey},
+		{"missing secret", "otpauth://totp/foo:bar", common.ErrorInvalidSecret},
+		{"invalid secret", "otpauth://totp/foo:bar?secret=1!1", common.ErrorInvalidSecret},
+		{"digits", "otpauth://totp/foo:bar?secret=GEZDGNBV&digits=12", common.ErrorInvalidCodeSize},
+		{"algorithm", "otpauth://totp/foo:bar?secret=GEZDGNBV&algorithm=MD5", common.ErrorUnknownAlgorithm},
+		{"uri", "otpauth://totp/%zz", common.ErrorInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKey(tt.uri)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestOtpKeyJSON(t *testing.T) {
+	key := newRotationTestKey(t)
+
+	data, err := json.Marshal(key)
+	require.NoError(t, err)
+
+	uri, _ := json.Marshal(key.String())
+	assert.Equal(t, string(uri), string(data))
+
+	var decoded OtpKey
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, key.String(), decoded.String())
+	assert.Equal(t, key.Secret(), decoded.Secret())
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`"otpauth://totp/foo:bar"`), &decoded), common.ErrorInvalidSecret)
+
+	_, err = json.Marshal(&OtpKey{})
+	assert.ErrorIs(t, err, common.ErrorNilOtpKey)
+}
+
+func TestOtpKeyBinary(t *testing.T) {
+	key := newRotationTestKey(t)
+
+	data, err := key.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded OtpKey
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, key.String(), decoded.String())
+}
+
+func TestOtpOptionsJSON(t *testing.T) {
+	options := &OtpOptions{CodeSize: common.EightDigits, Algorithm: common.SHA256Algorithm}
+
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"digits":8,"algorithm":"SHA256"}`, string(data))
+
+	var decoded OtpOptions
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *options, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"algorithm":"SHA512"}`), &decoded))
+	assert.Equal(t, OtpOptions{CodeSize: common.SixDigits, Algorithm: common.SHA512Algorithm}, decoded)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"digits":6,"period":30}`), &decoded), common.ErrorInvalidEncoding)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"digits":3}`), &decoded), common.ErrorInvalidCodeSize)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"algorithm":"MD5"}`), &decoded), common.ErrorUnknownAlgorithm)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`[]`), &decoded), common.ErrorInvalidEncoding)
+}
+
+func TestOtpOptionsBinary(t *testing.T) {
+	options := &OtpOptions{CodeSize: common.SevenDigits, Algorithm: common.SHA3_256Algorithm}
+
+	data, err := options.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded OtpOptions
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, *options, decoded)
+
+	assert.ErrorIs(t, decoded.UnmarshalBinary(nil), common.ErrorInvalidEncoding)
+	assert.ErrorIs(t, decoded.UnmarshalBinary([]byte{2, 6, 'S'}), common.ErrorInvalidEncoding)
+
+	options.Binding = "tx:4f2a"
+	data, err = options.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, *options, decoded)
+}
+
+func TestSecretRotationJSON(t *testing.T) {
+	key := newRotationTestKey(t)
+	rotation, err := RotateKey(key, DefaultRotationGracePeriod, time.Now().UTC())
+	require.NoError(t, err)
+
+	data, err := json.Marshal(rotation)
+	require.NoError(t, err)
+
+	var decoded SecretRotation
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, rotation.Key.String(), decoded.Key.String())
+	assert.Equal(t, rotation.Previous.Value(), decoded.Previous.Value())
+	assert.True(t, rotation.ExpiresAt.Equal(decoded.ExpiresAt))
+}
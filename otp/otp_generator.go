@@ -0,0 +1,141 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+	"math"
+	"sync"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+// Generator computes the codes of a single secret, the secret is decoded
+// and the HMAC keyed once so generating and validating codes, or a whole
+// window of them, does not decode or allocate again. It is safe for
+// concurrent use.
+type Generator struct {
+	options    OtpOptions
+	binding    []byte
+	normalizer *CodeNormalizer
+
+	mu      sync.Mutex
+	mac     hash.Hash
+	counter [8]byte
+	sum     []byte
+}
+
+func NewGenerator(secret string, options *OtpOptions) (*Generator, error) {
+	if options == nil {
+		options = NewDefaultOtpOptions()
+	}
+
+	result := Generator{
+		options: options.withDefaults(),
+		binding: []byte(options.Binding),
+	}
+
+	if err := result.options.CodeSize.Validate(); err != nil {
+		return nil, common.WithField(err, "CodeSize")
+	}
+
+	hashFunc, err := result.options.Algorithm.HashFunc()
+	if err != nil {
+		return nil, common.WithField(err, "Algorithm")
+	}
+
+	secretBytes, err := decodeSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	result.mac = hmac.New(hashFunc, secretBytes)
+	result.sum = make([]byte, 0, result.mac.Size())
+
+	return &result, nil
+}
+
+// Options returns the options the generator was created with, a zero
+// CodeSize is returned as six digits.
+func (g *Generator) Options() OtpOptions {
+	return g.options
+}
+
+// SetNormalizer sets the normalizer ValidateWindow applies to the codes, nil
+// uses the default one. It must be set before the generator is shared.
+func (g *Generator) SetNormalizer(normalizer *CodeNormalizer) {
+	g.normalizer = normalizer
+}
+
+func (g *Generator) Generate(counter uint64) string {
+	var buff [common.MaxCodeSize]byte
+	return string(g.AppendCode(buff[:0], counter))
+}
+
+// AppendCode appends the code for the counter to dst.
+func (g *Generator) AppendCode(dst []byte, counter uint64) []byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.appendCode(dst, counter)
+}
+
+func (g *Generator) Validate(code string, counter uint64) (bool, error) {
+	_, valid, err := g.ValidateWindow(code, counter, 0, 0)
+	return valid, err
+}
+
+// ValidateWindow checks the current counter first and then moves outward
+// one step at a time, trying the past step before the future one, it
+// returns the offset of the matching step from the counter. The code is
+// normalized first, see SetNormalizer.
+func (g *Generator) ValidateWindow(code string, counter uint64, past uint, future uint) (int64, bool, error) {
+	code = g.normalizer.Normalize(code)
+	if len(code) != g.options.CodeSize.Length() {
+		return 0, false, common.ErrorWrongCodeSize.WithField("code")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.matches(code, counter) {
+		return 0, true, nil
+	}
+
+	for i := uint64(1); i <= uint64(max(past, future)); i++ {
+		if i <= uint64(past) && i <= counter && g.matches(code, counter-i) {
+			return -int64(i), true, nil
+		}
+
+		if i <= uint64(future) && counter <= math.MaxUint64-i && g.matches(code, counter+i) {
+			return int64(i), true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (g *Generator) matches(code string, counter uint64) bool {
+	var buff [common.MaxCodeSize]byte
+	expected := g.appendCode(buff[:0], counter)
+
+	return subtle.ConstantTimeCompare([]byte(code), expected) == 1
+}
+
+func (g *Generator) appendCode(dst []byte, counter uint64) []byte {
+	binary.BigEndian.PutUint64(g.counter[:], counter)
+
+	g.mac.Reset()
+	g.mac.Write(g.counter[:])
+	g.mac.Write(g.binding)
+	g.sum = g.mac.Sum(g.sum[:0])
+
+	offset := g.sum[len(g.sum)-1] & 0xf
+	value := uint64(g.sum[offset]&0x7f)<<24 |
+		uint64(g.sum[offset+1])<<16 |
+		uint64(g.sum[offset+2])<<8 |
+		uint64(g.sum[offset+3])
+
+	return g.options.CodeSize.AppendFormat(dst, value%g.options.CodeSize.Modulus())
+}
@@ -0,0 +1,152 @@
+package otp
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorRFCMatrix(t *testing.T) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(t, err)
+
+	for _, entry := range rfcTestMatrix {
+		assert.Equal(t, entry.Code, generator.Generate(entry.Counter))
+
+		valid, err := generator.Validate(" "+entry.Code+" ", entry.Counter)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	assert.Equal(t, common.SixDigits, generator.Options().CodeSize)
+}
+
+func TestGeneratorMatchesGenerateCode(t *testing.T) {
+	for _, options := range []*OtpOptions{
+		{CodeSize: common.EightDigits, Algorithm: common.SHA256Algorithm},
+		{CodeSize: common.TenDigits, Algorithm: common.SHA3_512Algorithm},
+		{CodeSize: common.FourDigits, Algorithm: common.SHA1Algorithm, Binding: "login"},
+	} {
+		generator, err := NewGenerator(sha1Secret, options)
+		require.NoError(t, err)
+
+		for counter := uint64(0); counter < 50; counter++ {
+			expected, err := GenerateCode(sha1Secret, counter, options)
+			require.NoError(t, err)
+			assert.Equal(t, expected, generator.Generate(counter))
+		}
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	_, err := NewGenerator(sha1Secret, &OtpOptions{CodeSize: common.PassCodeSize(12)})
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+
+	_, err = NewGenerator(sha1Secret, &OtpOptions{Algorithm: common.Algorithm(99)})
+	assert.ErrorIs(t, err, common.ErrorUnknownAlgorithm)
+
+	_, err = NewGenerator("not base32!", nil)
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(t, err)
+	_, err = generator.Validate("12345", 0)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
+}
+
+func TestGeneratorValidateWindow(t *testing.T) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(t, err)
+
+	for counter := uint64(8); counter <= 12; counter++ {
+		offset, valid, err := generator.ValidateWindow(generator.Generate(counter), 10, 2, 2)
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, int64(counter)-10, offset)
+	}
+
+	_, valid, err := generator.ValidateWindow(generator.Generate(13), 10, 2, 2)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	_, valid, err = generator.ValidateWindow(generator.Generate(9), 10, 0, 3)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestGeneratorDoesNotAllocate(t *testing.T) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(t, err)
+
+	buff := make([]byte, 0, common.MaxCodeSize)
+	allocs := testing.AllocsPerRun(100, func() {
+		buff = generator.AppendCode(buff[:0], 1)
+	})
+	assert.Zero(t, allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		_, _, _ = generator.ValidateWindow("000000", 10, 2, 2)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestGeneratorConcurrentUse(t *testing.T) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, entry := range rfcTestMatrix {
+				assert.Equal(t, entry.Code, generator.Generate(entry.Counter))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkGenerateCode(b *testing.B) {
+	options := NewDefaultOtpOptions()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = GenerateCode(sha1Secret, uint64(i), options)
+	}
+}
+
+func BenchmarkGeneratorAppendCode(b *testing.B) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(b, err)
+
+	buff := make([]byte, 0, common.MaxCodeSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buff = generator.AppendCode(buff[:0], uint64(i))
+	}
+}
+
+func BenchmarkValidateCodeWindow(b *testing.B) {
+	options := NewDefaultOtpOptions()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for counter := uint64(8); counter <= 12; counter++ {
+			_, _ = ValidateCode("000000", counter, sha1Secret, options)
+		}
+	}
+}
+
+func BenchmarkGeneratorValidateWindow(b *testing.B) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = generator.ValidateWindow("000000", 10, 2, 2)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"image"
 	"image/png"
+	"log/slog"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/cjlapao/common-go-identity-otp/common"
@@ -65,10 +67,92 @@ func (k *OtpKey) Algorithm() string {
 	return q.Get("algorithm")
 }
 
+func (k *OtpKey) HashAlgorithm() (common.Algorithm, error) {
+	algorithm := k.Algorithm()
+	if algorithm == "" {
+		return common.SHA1Algorithm, nil
+	}
+
+	result, err := common.ParseAlgorithm(algorithm)
+	if err != nil {
+		return 0, common.WithField(err, "algorithm")
+	}
+
+	return result, nil
+}
+
+func (k *OtpKey) Digits() (common.PassCodeSize, error) {
+	q := k.url.Query()
+
+	digits := q.Get("digits")
+	if digits == "" {
+		return common.SixDigits, nil
+	}
+
+	result, err := common.ParsePassCodeSize(digits)
+	if err != nil {
+		return 0, common.WithField(err, "digits")
+	}
+
+	return result, nil
+}
+
+// Period returns the TOTP period in seconds, 30 when the uri does not set
+// one.
+func (k *OtpKey) Period() (uint, error) {
+	q := k.url.Query()
+
+	period := q.Get("period")
+	if period == "" {
+		return 30, nil
+	}
+
+	result, err := strconv.ParseUint(period, 10, 32)
+	if err != nil || result == 0 {
+		return 0, common.ErrorInvalidKey.WithField("period")
+	}
+
+	return uint(result), nil
+}
+
+// Counter returns the initial HOTP counter of the uri, zero when it does not
+// set one.
+func (k *OtpKey) Counter() (uint64, error) {
+	q := k.url.Query()
+
+	counter := q.Get("counter")
+	if counter == "" {
+		return 0, nil
+	}
+
+	result, err := strconv.ParseUint(counter, 10, 64)
+	if err != nil {
+		return 0, common.ErrorInvalidKey.WithField("counter")
+	}
+
+	return result, nil
+}
+
+func (k *OtpKey) Options() (*OtpOptions, error) {
+	codeSize, err := k.Digits()
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := k.HashAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOtpOptions(codeSize, algorithm)
+}
+
 func (k *OtpKey) Image() (image.Image, error) {
-	var pngImg []byte
-	pngImg, err := qrcode.Encode(k.raw, qrcode.Highest, common.DEFAULT_IMAGE_SIZE)
+	return k.ImageWithOptions(nil)
+}
 
+func (k *OtpKey) ImageWithOptions(options *QrOptions) (image.Image, error) {
+	pngImg, err := k.PngWithOptions(options)
 	if err != nil {
 		return nil, err
 	}
@@ -78,12 +162,35 @@ func (k *OtpKey) Image() (image.Image, error) {
 }
 
 func (k *OtpKey) Png() ([]byte, error) {
+	return k.PngWithOptions(nil)
+}
+
+func (k *OtpKey) PngWithOptions(options *QrOptions) ([]byte, error) {
+	if options == nil {
+		options = NewDefaultQrOptions()
+	}
+
 	var pngImg []byte
-	pngImg, err := qrcode.Encode(k.raw, qrcode.Highest, common.DEFAULT_IMAGE_SIZE)
+	pngImg, err := qrcode.Encode(k.raw, options.RecoveryLevel, options.Size)
 
 	return pngImg, err
 }
 
+// LogValue logs the key metadata without the secret.
+func (k *OtpKey) LogValue() slog.Value {
+	if k == nil || k.url == nil {
+		return slog.StringValue(RedactedValue)
+	}
+
+	return slog.GroupValue(
+		slog.String("type", k.Type()),
+		slog.String("issuer", k.Issuer()),
+		slog.String("user_id", k.UserId()),
+		slog.String("algorithm", k.Algorithm()),
+		slog.String("digits", k.url.Query().Get("digits")),
+	)
+}
+
 func NewKeyFromUrl(keyUrl url.URL) (*OtpKey, error) {
 	s := keyUrl.String()
 
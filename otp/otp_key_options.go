@@ -5,6 +5,8 @@ type OtpKeyOptions struct {
 	UserId  string
 	Secret  *OtpSecret
 	Options *OtpOptions
+	// Policy overrides the default policy for this key.
+	Policy *Policy
 }
 
 func NewDefaultOtpKeyOptions(issuer string, userId string) *OtpKeyOptions {
@@ -4,6 +4,8 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/skip2/go-qrcode"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -119,3 +121,143 @@ func TestKeyIssuerPngImage(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, img)
 }
+
+func TestKeyPngWithOptions(t *testing.T) {
+	keyUrl := url.URL{
+		Scheme: "otpauth",
+		Host:   "hotp",
+		Path:   "foobar@example.com",
+	}
+	key, err := NewKeyFromUrl(keyUrl)
+	assert.Nil(t, err)
+
+	img, err := key.ImageWithOptions(&QrOptions{Size: 128, RecoveryLevel: qrcode.Low})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 128, img.Bounds().Dx())
+}
+
+func TestKeyDigits(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		want     common.PassCodeSize
+		wantErr  error
+	}{
+		{"missing digits defaults to six", "", common.SixDigits, nil},
+		{"nine digits", "digits=9", common.NineDigits, nil},
+		{"four digits", "digits=4", common.FourDigits, nil},
+		{"unsupported digits", "digits=12", 0, common.ErrorInvalidCodeSize},
+		{"invalid digits", "digits=abc", 0, common.ErrorInvalidCodeSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewKeyFromUrl(url.URL{
+				Scheme:   "otpauth",
+				Host:     "totp",
+				Path:     "foobar:foobar@example.com",
+				RawQuery: tt.rawQuery,
+			})
+			assert.Nil(t, err)
+
+			got, err := key.Digits()
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKeyHashAlgorithm(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		want     common.Algorithm
+		wantErr  error
+	}{
+		{"missing algorithm defaults to sha1", "", common.SHA1Algorithm, nil},
+		{"sha256", "algorithm=SHA256", common.SHA256Algorithm, nil},
+		{"sha3", "algorithm=SHA3-256", common.SHA3_256Algorithm, nil},
+		{"unknown algorithm", "algorithm=MD4", 0, common.ErrorUnknownAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewKeyFromUrl(url.URL{
+				Scheme:   "otpauth",
+				Host:     "totp",
+				Path:     "foobar:foobar@example.com",
+				RawQuery: tt.rawQuery,
+			})
+			assert.Nil(t, err)
+
+			got, err := key.HashAlgorithm()
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKeyOptions(t *testing.T) {
+	key, err := NewKeyFromUrl(url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "foobar:foobar@example.com",
+		RawQuery: "algorithm=SHA512&digits=8",
+	})
+	assert.Nil(t, err)
+
+	options, err := key.Options()
+	assert.NoError(t, err)
+	assert.Equal(t, common.EightDigits, options.CodeSize)
+	assert.Equal(t, common.SHA512Algorithm, options.Algorithm)
+
+	key, err = NewKeyFromUrl(url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "foobar:foobar@example.com",
+		RawQuery: "algorithm=SHA512&digits=2",
+	})
+	assert.Nil(t, err)
+
+	options, err = key.Options()
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+	assert.Nil(t, options)
+}
+
+func TestKeyCounter(t *testing.T) {
+	key, err := NewKeyFromUrl(url.URL{Scheme: "otpauth", Host: "hotp", Path: "/foo:bar", RawQuery: "counter=42"})
+	assert.Nil(t, err)
+
+	counter, err := key.Counter()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), counter)
+
+	key, err = NewKeyFromUrl(url.URL{Scheme: "otpauth", Host: "hotp", Path: "/foo:bar", RawQuery: "counter=x"})
+	assert.Nil(t, err)
+
+	_, err = key.Counter()
+	assert.ErrorIs(t, err, common.ErrorInvalidKey)
+}
+
+func TestKeyPeriod(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		want     uint
+		wantErr  error
+	}{
+		{"", 30, nil},
+		{"period=60", 60, nil},
+		{"period=0", 0, common.ErrorInvalidKey},
+		{"period=-30", 0, common.ErrorInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawQuery, func(t *testing.T) {
+			key, err := NewKeyFromUrl(url.URL{Scheme: "otpauth", Host: "totp", Path: "/foo:bar", RawQuery: tt.rawQuery})
+			assert.Nil(t, err)
+
+			period, err := key.Period()
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, period)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package otp
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+const RedactedValue = "[REDACTED]"
+
+var defaultLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	SetLogger(nil)
+}
+
+// SetLogger sets the logger used by the package functions and by the
+// validators that were not given one, a nil logger discards all the events.
+func SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(discardHandler{})
+	}
+
+	defaultLogger.Store(logger)
+}
+
+func Logger() *slog.Logger {
+	return defaultLogger.Load()
+}
+
+// ToggleDebug used to print the counters and HMAC values to stdout, it does
+// nothing now and will be removed in the next release.
+//
+// Deprecated: use SetLogger or the Logger field of the generators and
+// validators instead, secrets and codes are never logged.
+func ToggleDebug() {}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
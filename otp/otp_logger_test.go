@@ -0,0 +1,71 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	buffer := &bytes.Buffer{}
+	SetLogger(slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: dropTime,
+	})))
+	t.Cleanup(func() {
+		SetLogger(nil)
+	})
+
+	return buffer
+}
+
+// dropTime keeps the timestamp digits from matching a code by chance.
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+
+	return a
+}
+
+func TestGenerateCodeLogsWithoutSecretOrCode(t *testing.T) {
+	logs := captureLogs(t)
+	entry := rfcTestMatrix[1]
+
+	code, err := GenerateCode(entry.Secret, entry.Counter, nil)
+	require.NoError(t, err)
+	valid, err := ValidateCode(code, entry.Counter, entry.Secret, nil)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	assert.Contains(t, logs.String(), `"msg":"otp code generated"`)
+	assert.Contains(t, logs.String(), `"msg":"otp code validated"`)
+	assert.Contains(t, logs.String(), `"counter":1`)
+	assert.NotContains(t, logs.String(), entry.Secret)
+	assert.NotContains(t, logs.String(), code)
+}
+
+func TestSetNilLoggerDiscardsEvents(t *testing.T) {
+	SetLogger(nil)
+
+	assert.NotNil(t, Logger())
+	assert.False(t, Logger().Enabled(context.Background(), slog.LevelError))
+}
+
+func TestSecretAndKeyAreRedacted(t *testing.T) {
+	logs := captureLogs(t)
+	key, err := GenerateKey("totp", NewDefaultOtpKeyOptions("foobar", "foobar@example.com"))
+	require.NoError(t, err)
+	secret := NewSecret(key.Secret())
+
+	Logger().Info("enrolled", slog.Any("key", key), slog.Any("secret", secret))
+
+	assert.Contains(t, logs.String(), `"issuer":"foobar"`)
+	assert.Contains(t, logs.String(), `"user_id":"foobar@example.com"`)
+	assert.Contains(t, logs.String(), `"secret":"[REDACTED]"`)
+	assert.NotContains(t, logs.String(), key.Secret())
+}
@@ -0,0 +1,108 @@
+package otp
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type EventKind string
+
+const (
+	GenerateEvent EventKind = "generate"
+	ValidateEvent EventKind = "validate"
+	EnrollEvent   EventKind = "enroll"
+	LockoutEvent  EventKind = "lockout"
+)
+
+type Outcome string
+
+const (
+	SuccessOutcome Outcome = "success"
+	FailureOutcome Outcome = "failure"
+	ErrorOutcome   Outcome = "error"
+	// LockedOutOutcome is the outcome of lockout events, it keeps them out
+	// of the success rates.
+	LockedOutOutcome Outcome = "locked_out"
+)
+
+// Event describes a single generate, validate, enroll or lockout operation,
+// it never carries secrets or codes.
+type Event struct {
+	Kind      EventKind
+	Type      string
+	Outcome   Outcome
+	Algorithm string
+	// Drift is the offset, in steps or counters, of the matching code.
+	Drift   int64
+	Latency time.Duration
+	Err     error
+}
+
+type Observer interface {
+	Observe(ctx context.Context, event Event)
+}
+
+type ObserverFunc func(ctx context.Context, event Event)
+
+func (f ObserverFunc) Observe(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) Observe(ctx context.Context, event Event) {
+	for _, observer := range m {
+		observer.Observe(ctx, event)
+	}
+}
+
+func MultiObserver(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+type observerHolder struct {
+	observer Observer
+}
+
+var defaultObserver atomic.Pointer[observerHolder]
+
+func init() {
+	SetObserver(nil)
+}
+
+// SetObserver sets the observer used by the package functions and by the
+// validators that were not given one, a nil observer ignores all the events.
+func SetObserver(observer Observer) {
+	if observer == nil {
+		observer = ObserverFunc(func(context.Context, Event) {})
+	}
+
+	defaultObserver.Store(&observerHolder{observer: observer})
+}
+
+func DefaultObserver() Observer {
+	return defaultObserver.Load().observer
+}
+
+func OutcomeOf(valid bool, err error) Outcome {
+	switch {
+	case err != nil:
+		return ErrorOutcome
+	case valid:
+		return SuccessOutcome
+	default:
+		return FailureOutcome
+	}
+}
+
+// Notify sets the latency since start and sends the event to the observer,
+// when it is nil to the one carried by ctx or else the default one.
+func Notify(ctx context.Context, observer Observer, event Event, start time.Time) {
+	if observer == nil {
+		observer = ObserverFromContext(ctx)
+	}
+
+	event.Latency = time.Since(start)
+	observer.Observe(ctx, event)
+}
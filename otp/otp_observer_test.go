@@ -0,0 +1,87 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureEvents(t *testing.T) *[]Event {
+	events := &[]Event{}
+	SetObserver(ObserverFunc(func(_ context.Context, event Event) {
+		*events = append(*events, event)
+	}))
+	t.Cleanup(func() {
+		SetObserver(nil)
+	})
+
+	return events
+}
+
+func TestGenerateKeyNotifiesEnrollment(t *testing.T) {
+	events := captureEvents(t)
+
+	_, err := GenerateKey("TOTP", NewDefaultOtpKeyOptions("foobar", "foobar@example.com"))
+	require.NoError(t, err)
+	_, err = GenerateKey("hotp", &OtpKeyOptions{UserId: "foobar@example.com"})
+	require.Error(t, err)
+
+	require.Len(t, *events, 2)
+	assert.Equal(t, EnrollEvent, (*events)[0].Kind)
+	assert.Equal(t, "totp", (*events)[0].Type)
+	assert.Equal(t, SuccessOutcome, (*events)[0].Outcome)
+	assert.Equal(t, "SHA1", (*events)[0].Algorithm)
+	assert.Equal(t, ErrorOutcome, (*events)[1].Outcome)
+	assert.Equal(t, err, (*events)[1].Err)
+}
+
+func TestGenerateAndValidateCodeNotify(t *testing.T) {
+	events := captureEvents(t)
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	code, err := GenerateCode(secret, 0, nil)
+	require.NoError(t, err)
+	valid, err := ValidateCode(code, 0, secret, nil)
+	require.NoError(t, err)
+	require.True(t, valid)
+	_, err = ValidateCode("123", 0, secret, nil)
+	require.Error(t, err)
+
+	require.Len(t, *events, 3)
+	assert.Equal(t, GenerateEvent, (*events)[0].Kind)
+	assert.Equal(t, "otp", (*events)[0].Type)
+	assert.Equal(t, SuccessOutcome, (*events)[0].Outcome)
+	assert.Equal(t, "SHA1", (*events)[0].Algorithm)
+	assert.Equal(t, ValidateEvent, (*events)[1].Kind)
+	assert.Equal(t, SuccessOutcome, (*events)[1].Outcome)
+	assert.Equal(t, ErrorOutcome, (*events)[2].Outcome)
+}
+
+func TestMultiObserver(t *testing.T) {
+	count := 0
+	counter := ObserverFunc(func(context.Context, Event) {
+		count++
+	})
+
+	Notify(context.Background(), MultiObserver(counter, counter), Event{Kind: LockoutEvent}, time.Now())
+	assert.Equal(t, 2, count)
+}
+
+func TestNotifySetsLatency(t *testing.T) {
+	events := captureEvents(t)
+
+	Notify(context.Background(), nil, Event{Kind: ValidateEvent}, time.Now().Add(-time.Second))
+
+	require.Len(t, *events, 1)
+	assert.GreaterOrEqual(t, (*events)[0].Latency, time.Second)
+}
+
+func TestOutcomeOf(t *testing.T) {
+	assert.Equal(t, SuccessOutcome, OutcomeOf(true, nil))
+	assert.Equal(t, FailureOutcome, OutcomeOf(false, nil))
+	assert.Equal(t, ErrorOutcome, OutcomeOf(true, errors.New("boom")))
+}
@@ -3,8 +3,12 @@ package otp
 import "github.com/cjlapao/common-go-identity-otp/common"
 
 type OtpOptions struct {
-	CodeSize  common.PassCodeSize
-	Algorithm common.Algorithm
+	CodeSize  common.PassCodeSize `json:"digits"`
+	Algorithm common.Algorithm    `json:"algorithm"`
+	// Binding is mixed into the HMAC input after the counter so a code only
+	// validates for the same context, e.g. "login" or a transaction hash,
+	// authenticator apps can not generate bound codes.
+	Binding string `json:"binding,omitempty"`
 }
 
 func NewDefaultOtpOptions() *OtpOptions {
@@ -15,3 +19,40 @@ func NewDefaultOtpOptions() *OtpOptions {
 
 	return &result
 }
+
+func NewOtpOptions(codeSize common.PassCodeSize, algorithm common.Algorithm) (*OtpOptions, error) {
+	if err := codeSize.Validate(); err != nil {
+		return nil, common.WithField(err, "CodeSize")
+	}
+
+	if err := algorithm.Validate(); err != nil {
+		return nil, common.WithField(err, "Algorithm")
+	}
+
+	result := OtpOptions{
+		CodeSize:  codeSize,
+		Algorithm: algorithm,
+	}
+
+	return &result, nil
+}
+
+func (o *OtpOptions) Validate() error {
+	if err := o.CodeSize.Validate(); err != nil {
+		return common.WithField(err, "CodeSize")
+	}
+
+	if err := o.Algorithm.Validate(); err != nil {
+		return common.WithField(err, "Algorithm")
+	}
+
+	return nil
+}
+
+func (o OtpOptions) withDefaults() OtpOptions {
+	if o.CodeSize == 0 {
+		o.CodeSize = common.SixDigits
+	}
+
+	return o
+}
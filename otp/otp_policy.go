@@ -0,0 +1,195 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+// Policy restricts the keys that can be created and validated, a zero value
+// in any of the fields disables that rule.
+type Policy struct {
+	// MinSecretSize is the minimum secret length in bytes.
+	MinSecretSize uint
+	// Algorithms is the list of allowed algorithms, empty allows all the
+	// registered ones.
+	Algorithms  []common.Algorithm
+	MinCodeSize common.PassCodeSize
+	MaxCodeSize common.PassCodeSize
+	// MinPeriod and MaxPeriod bound the TOTP period in seconds.
+	MinPeriod uint
+	MaxPeriod uint
+	// MaxSkew is the most TOTP steps accepted on either side of the current
+	// one.
+	MaxSkew uint
+}
+
+// StrictPolicy follows RFC 4226 recommendations, secrets of at least 128
+// bits and no less than six digits. SHA-1 is kept as it is the algorithm
+// most authenticator apps support, the SHA-3 ones are left out as no app
+// supports them.
+func StrictPolicy() *Policy {
+	result := Policy{
+		MinSecretSize: 16,
+		Algorithms: []common.Algorithm{
+			common.SHA1Algorithm,
+			common.SHA256Algorithm,
+			common.SHA512Algorithm,
+		},
+		MinCodeSize: common.SixDigits,
+		MaxCodeSize: common.EightDigits,
+		MinPeriod:   30,
+		MaxPeriod:   60,
+		MaxSkew:     1,
+	}
+
+	return &result
+}
+
+type policyHolder struct {
+	policy *Policy
+}
+
+var defaultPolicy atomic.Pointer[policyHolder]
+
+func init() {
+	SetPolicy(nil)
+}
+
+// SetPolicy sets the policy enforced by GenerateKey, NewRandomOtpSecret,
+// NewSecretWithPolicy, ValidateCode, the totp and hotp Validate functions and
+// the validators that were not given one, nil enforces nothing.
+func SetPolicy(policy *Policy) {
+	if policy == nil {
+		policy = &Policy{}
+	}
+
+	defaultPolicy.Store(&policyHolder{policy: policy})
+}
+
+func DefaultPolicy() *Policy {
+	return defaultPolicy.Load().policy
+}
+
+func violation(field string, format string, args ...any) error {
+	return common.ErrorPolicyViolation.WithField(field).WithCause(fmt.Errorf(format, args...))
+}
+
+func (p *Policy) CheckSecretSize(size uint) error {
+	if p == nil || size >= p.MinSecretSize {
+		return nil
+	}
+
+	return violation("secret", "secret is %d bytes, at least %d are required", size, p.MinSecretSize)
+}
+
+// CheckSecret checks the size of a base32 encoded secret, the secret is
+// only decoded when a minimum size is set.
+func (p *Policy) CheckSecret(secret string) error {
+	if p == nil || p.MinSecretSize == 0 {
+		return nil
+	}
+
+	secretBytes, err := decodeSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	return p.CheckSecretSize(uint(len(secretBytes)))
+}
+
+func (p *Policy) CheckAlgorithm(algorithm common.Algorithm) error {
+	if p == nil || len(p.Algorithms) == 0 || slices.Contains(p.Algorithms, algorithm) {
+		return nil
+	}
+
+	return violation("algorithm", "%s is not one of the allowed algorithms", algorithm)
+}
+
+func (p *Policy) CheckCodeSize(codeSize common.PassCodeSize) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.MinCodeSize != 0 && codeSize < p.MinCodeSize {
+		return violation("digits", "%d digits is less than the minimum of %d", codeSize, p.MinCodeSize)
+	}
+
+	if p.MaxCodeSize != 0 && codeSize > p.MaxCodeSize {
+		return violation("digits", "%d digits is more than the maximum of %d", codeSize, p.MaxCodeSize)
+	}
+
+	return nil
+}
+
+func (p *Policy) CheckPeriod(period uint) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.MinPeriod != 0 && period < p.MinPeriod {
+		return violation("period", "%d seconds is less than the minimum of %d", period, p.MinPeriod)
+	}
+
+	if p.MaxPeriod != 0 && period > p.MaxPeriod {
+		return violation("period", "%d seconds is more than the maximum of %d", period, p.MaxPeriod)
+	}
+
+	return nil
+}
+
+func (p *Policy) CheckSkew(past uint, future uint) error {
+	if p == nil || p.MaxSkew == 0 {
+		return nil
+	}
+
+	var result []error
+	if past > p.MaxSkew {
+		result = append(result, violation("past_skew", "%d steps is more than the maximum of %d", past, p.MaxSkew))
+	}
+
+	if future > p.MaxSkew {
+		result = append(result, violation("future_skew", "%d steps is more than the maximum of %d", future, p.MaxSkew))
+	}
+
+	return errors.Join(result...)
+}
+
+// CheckOptions returns all the violations of the options joined in a single
+// error, a zero code size is checked as six digits.
+func (p *Policy) CheckOptions(options *OtpOptions) error {
+	if p == nil || options == nil {
+		return nil
+	}
+
+	checked := options.withDefaults()
+
+	return errors.Join(
+		p.CheckCodeSize(checked.CodeSize),
+		p.CheckAlgorithm(checked.Algorithm),
+	)
+}
+
+// CheckKey checks the options and secret of an existing key.
+func (p *Policy) CheckKey(key *OtpKey) error {
+	if p == nil {
+		return nil
+	}
+
+	if key == nil || key.url == nil {
+		return common.ErrorNilOtpKey.WithField("key")
+	}
+
+	options, err := key.Options()
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(
+		p.CheckSecret(key.Secret()),
+		p.CheckOptions(options),
+	)
+}
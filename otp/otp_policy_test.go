@@ -0,0 +1,133 @@
+package otp
+
+import (
+	"encoding/base32"
+	"testing"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useStrictPolicy(t *testing.T) {
+	SetPolicy(StrictPolicy())
+	t.Cleanup(func() { SetPolicy(nil) })
+}
+
+func TestZeroPolicyAllowsEverything(t *testing.T) {
+	policy := &Policy{}
+
+	assert.NoError(t, policy.CheckSecret("not base32"))
+	assert.NoError(t, policy.CheckOptions(&OtpOptions{CodeSize: common.FourDigits, Algorithm: common.SHA1Algorithm}))
+	assert.NoError(t, policy.CheckPeriod(600))
+	assert.NoError(t, policy.CheckSkew(10, 10))
+
+	var nilPolicy *Policy
+	assert.NoError(t, nilPolicy.CheckSecret("not base32"))
+	assert.NoError(t, nilPolicy.CheckKey(nil))
+}
+
+func TestStrictPolicyViolations(t *testing.T) {
+	policy := StrictPolicy()
+
+	shortSecret := base32.StdEncoding.EncodeToString(make([]byte, 10))
+	err := policy.CheckSecret(shortSecret)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "secret is 10 bytes, at least 16 are required")
+	assert.NoError(t, policy.CheckSecret(base32.StdEncoding.EncodeToString(make([]byte, 16))))
+	assert.ErrorIs(t, policy.CheckSecret("1!1"), common.ErrorInvalidSecret)
+
+	err = policy.CheckOptions(&OtpOptions{CodeSize: common.FourDigits, Algorithm: common.SHA3_256Algorithm})
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "digits: value is not allowed by the policy: 4 digits is less than the minimum of 6")
+	assert.ErrorContains(t, err, "algorithm: value is not allowed by the policy: SHA3-256 is not one of the allowed algorithms")
+	for _, algorithm := range []common.Algorithm{common.SHA1Algorithm, common.SHA256Algorithm, common.SHA512Algorithm} {
+		assert.NoError(t, policy.CheckAlgorithm(algorithm), algorithm)
+	}
+	assert.ErrorContains(t, policy.CheckCodeSize(common.TenDigits), "10 digits is more than the maximum of 8")
+
+	assert.ErrorContains(t, policy.CheckPeriod(120), "120 seconds is more than the maximum of 60")
+	assert.ErrorContains(t, policy.CheckPeriod(10), "10 seconds is less than the minimum of 30")
+	assert.NoError(t, policy.CheckPeriod(30))
+
+	err = policy.CheckSkew(2, 3)
+	assert.ErrorContains(t, err, "past_skew")
+	assert.ErrorContains(t, err, "future_skew")
+	assert.NoError(t, policy.CheckSkew(1, 1))
+}
+
+func TestGenerateKeyEnforcesPolicy(t *testing.T) {
+	useStrictPolicy(t)
+
+	_, err := GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Secret:  NewSecret(base32.StdEncoding.EncodeToString(make([]byte, 10))),
+		Options: &OtpOptions{CodeSize: common.SixDigits, Algorithm: common.SHA3_512Algorithm},
+	})
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "secret")
+	assert.ErrorContains(t, err, "algorithm")
+
+	key, err := GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &OtpOptions{CodeSize: common.SixDigits, Algorithm: common.SHA256Algorithm},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, StrictPolicy().CheckKey(key))
+
+	// a key level policy overrides the default one
+	_, err = GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: NewDefaultOtpOptions(),
+		Policy:  &Policy{},
+	})
+	assert.NoError(t, err)
+}
+
+func TestNewRandomOtpSecretUsesPolicyMinimum(t *testing.T) {
+	useStrictPolicy(t)
+
+	secret := NewRandomOtpSecret(1)
+	assert.Equal(t, uint(16), secret.SecretSize)
+	assert.NoError(t, StrictPolicy().CheckSecret(secret.Value()))
+}
+
+func TestNewSecretWithPolicy(t *testing.T) {
+	_, err := NewSecretWithPolicy(base32.StdEncoding.EncodeToString(make([]byte, 10)), StrictPolicy())
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+
+	_, err = NewSecretWithPolicy("1!1", nil)
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+
+	secret, err := NewSecretWithPolicy(base32.StdEncoding.EncodeToString(make([]byte, 20)), StrictPolicy())
+	require.NoError(t, err)
+	assert.NotEmpty(t, secret.Value())
+}
+
+func TestPackageFunctionsEnforceDefaultPolicy(t *testing.T) {
+	useStrictPolicy(t)
+	weak := base32.StdEncoding.EncodeToString(make([]byte, 10))
+	strong := base32.StdEncoding.EncodeToString(make([]byte, 20))
+
+	_, err := NewSecretWithPolicy(weak, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+
+	code, err := GenerateCode(weak, 0, nil)
+	require.NoError(t, err)
+	_, err = ValidateCode(code, 0, weak, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "secret")
+
+	_, err = ValidateCode("1234", 0, strong, &OtpOptions{CodeSize: common.FourDigits})
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "digits")
+
+	code, err = GenerateCode(strong, 0, nil)
+	require.NoError(t, err)
+	valid, err := ValidateCode(code, 0, strong, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
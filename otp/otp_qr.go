@@ -0,0 +1,22 @@
+package otp
+
+import (
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/skip2/go-qrcode"
+)
+
+type QrOptions struct {
+	// Size is the width and height of the image in pixels, a negative value
+	// sets the size of each module instead.
+	Size          int
+	RecoveryLevel qrcode.RecoveryLevel
+}
+
+func NewDefaultQrOptions() *QrOptions {
+	result := QrOptions{
+		Size:          common.DEFAULT_IMAGE_SIZE,
+		RecoveryLevel: qrcode.Highest,
+	}
+
+	return &result
+}
@@ -0,0 +1,126 @@
+package otp
+
+import (
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/helpers"
+)
+
+const DefaultRotationGracePeriod = 7 * 24 * time.Hour
+
+// SecretRotation holds a re-keyed credential, the previous secret keeps
+// validating until the grace period expires or the new key is first used.
+type SecretRotation struct {
+	Key       *OtpKey    `json:"key"`
+	Previous  *OtpSecret `json:"previous,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	// Counter is the next HOTP counter of the new key, a newly provisioned
+	// token starts at the counter of the uri whatever the counter of the
+	// previous secret.
+	Counter uint64 `json:"counter,omitempty"`
+}
+
+func RotateKey(key *OtpKey, gracePeriod time.Duration, t time.Time) (*SecretRotation, error) {
+	if key == nil || key.url == nil {
+		return nil, common.ErrorNilOtpKey.WithField("key")
+	}
+
+	options, err := key.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := NewSecret(key.Secret())
+	secretBytes, err := previous.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := GenerateKey(key.Type(), &OtpKeyOptions{
+		Issuer:  key.Issuer(),
+		UserId:  key.UserId(),
+		Secret:  NewRandomOtpSecret(len(secretBytes)),
+		Options: options,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// only the secret changes, the period, counter and any other parameter
+	// of the uri are kept
+	keyUrl := *key.url
+	query := key.url.Query()
+	query.Set("secret", generated.Secret())
+	keyUrl.RawQuery = helpers.EncodeQuery(query)
+
+	newKey, err := NewKeyFromUrl(keyUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	counter, err := newKey.Counter()
+	if err != nil {
+		return nil, err
+	}
+
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultRotationGracePeriod
+	}
+
+	result := SecretRotation{
+		Key:       newKey,
+		Previous:  previous,
+		ExpiresAt: t.Add(gracePeriod),
+		Counter:   counter,
+	}
+
+	return &result, nil
+}
+
+func (r *SecretRotation) InGracePeriod(t time.Time) bool {
+	return r.Previous != nil && t.Before(r.ExpiresAt)
+}
+
+// Secrets returns the secrets that are currently accepted, the new one first.
+func (r *SecretRotation) Secrets(t time.Time) []string {
+	secrets := []string{r.Key.Secret()}
+	if r.InGracePeriod(t) {
+		secrets = append(secrets, r.Previous.Value())
+	}
+
+	return secrets
+}
+
+// Validate runs the validate function against the new secret and, while in
+// the grace period, against the previous one, a successful validation with
+// the new secret or an expired grace period destroys the previous secret.
+func (r *SecretRotation) Validate(t time.Time, validate func(secret string) (bool, error)) (bool, error) {
+	valid, err := validate(r.Key.Secret())
+	if err != nil {
+		return false, err
+	}
+
+	if valid {
+		r.Complete()
+		return true, nil
+	}
+
+	if !r.InGracePeriod(t) {
+		r.Complete()
+		return false, nil
+	}
+
+	return validate(r.Previous.Value())
+}
+
+// Complete destroys the previous secret ending the grace period.
+func (r *SecretRotation) Complete() {
+	if r.Previous != nil {
+		r.Previous.value = ""
+		r.Previous.SecretSize = 0
+	}
+
+	r.Previous = nil
+	r.ExpiresAt = time.Time{}
+}
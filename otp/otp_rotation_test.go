@@ -0,0 +1,158 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRotationTestKey(t *testing.T) *OtpKey {
+	key, err := GenerateKey("hotp", &OtpKeyOptions{
+		Issuer: "foobar",
+		UserId: "foobar@example.com",
+		Secret: NewRandomOtpSecret(20),
+		Options: &OtpOptions{
+			CodeSize:  common.EightDigits,
+			Algorithm: common.SHA256Algorithm,
+		},
+	})
+	require.NoError(t, err)
+
+	return key
+}
+
+func validatorFor(code string, counter uint64, options *OtpOptions) func(secret string) (bool, error) {
+	return func(secret string) (bool, error) {
+		return ValidateCode(code, counter, secret, options)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	key := newRotationTestKey(t)
+	now := time.Now().UTC()
+
+	rotation, err := RotateKey(key, time.Hour, now)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, key.Secret(), rotation.Key.Secret())
+	assert.Equal(t, key.Secret(), rotation.Previous.Value())
+	assert.Equal(t, len(key.Secret()), len(rotation.Key.Secret()))
+	assert.Equal(t, key.Type(), rotation.Key.Type())
+	assert.Equal(t, key.Issuer(), rotation.Key.Issuer())
+	assert.Equal(t, key.UserId(), rotation.Key.UserId())
+	assert.Equal(t, key.Algorithm(), rotation.Key.Algorithm())
+	assert.Equal(t, now.Add(time.Hour), rotation.ExpiresAt)
+	assert.Equal(t, []string{rotation.Key.Secret(), key.Secret()}, rotation.Secrets(now))
+}
+
+func TestRotateKeyKeepsUriParameters(t *testing.T) {
+	key, err := ParseKey("otpauth://totp/foobar:foobar@example.com?algorithm=SHA512&digits=8&issuer=foobar&period=60&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+
+	rotation, err := RotateKey(key, time.Hour, time.Now())
+	require.NoError(t, err)
+	assert.NotEqual(t, key.Secret(), rotation.Key.Secret())
+
+	expected := key.url.Query()
+	actual := rotation.Key.url.Query()
+	expected.Del("secret")
+	actual.Del("secret")
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, key.url.Host, rotation.Key.url.Host)
+	assert.Equal(t, key.url.Path, rotation.Key.url.Path)
+
+	key, err = ParseKey("otpauth://hotp/foobar:foobar@example.com?algorithm=SHA256&counter=42&digits=7&issuer=foobar&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+
+	rotation, err = RotateKey(key, time.Hour, time.Now())
+	require.NoError(t, err)
+
+	counter, err := rotation.Key.Counter()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), counter)
+	assert.Equal(t, uint64(42), rotation.Counter)
+	assert.Equal(t, "7", rotation.Key.url.Query().Get("digits"))
+	assert.Equal(t, "SHA256", rotation.Key.Algorithm())
+}
+
+func TestRotateKeyWithDefaultGracePeriod(t *testing.T) {
+	now := time.Now().UTC()
+
+	rotation, err := RotateKey(newRotationTestKey(t), 0, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(DefaultRotationGracePeriod), rotation.ExpiresAt)
+}
+
+func TestRotateKeyWithNilKey(t *testing.T) {
+	rotation, err := RotateKey(nil, time.Hour, time.Now())
+
+	assert.ErrorIs(t, err, common.ErrorNilOtpKey)
+	assert.Nil(t, rotation)
+}
+
+func TestRotationAcceptsPreviousSecretDuringGracePeriod(t *testing.T) {
+	key := newRotationTestKey(t)
+	options, err := key.Options()
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	rotation, err := RotateKey(key, time.Hour, now)
+	require.NoError(t, err)
+
+	oldCode, err := GenerateCode(key.Secret(), 1, options)
+	require.NoError(t, err)
+
+	valid, err := rotation.Validate(now.Add(time.Minute), validatorFor(oldCode, 1, options))
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.NotNil(t, rotation.Previous)
+}
+
+func TestRotationDestroysPreviousSecretOnFirstNewValidation(t *testing.T) {
+	key := newRotationTestKey(t)
+	options, err := key.Options()
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	rotation, err := RotateKey(key, time.Hour, now)
+	require.NoError(t, err)
+	previous := rotation.Previous
+
+	newCode, err := GenerateCode(rotation.Key.Secret(), 1, options)
+	require.NoError(t, err)
+
+	valid, err := rotation.Validate(now, validatorFor(newCode, 1, options))
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Nil(t, rotation.Previous)
+	assert.Equal(t, "", previous.Value())
+	assert.Equal(t, []string{rotation.Key.Secret()}, rotation.Secrets(now))
+
+	oldCode, err := GenerateCode(key.Secret(), 2, options)
+	require.NoError(t, err)
+
+	valid, err = rotation.Validate(now, validatorFor(oldCode, 2, options))
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestRotationRejectsPreviousSecretAfterGracePeriod(t *testing.T) {
+	key := newRotationTestKey(t)
+	options, err := key.Options()
+	require.NoError(t, err)
+	now := time.Now().UTC()
+
+	rotation, err := RotateKey(key, time.Hour, now)
+	require.NoError(t, err)
+
+	oldCode, err := GenerateCode(key.Secret(), 1, options)
+	require.NoError(t, err)
+
+	valid, err := rotation.Validate(now.Add(2*time.Hour), validatorFor(oldCode, 1, options))
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Nil(t, rotation.Previous)
+}
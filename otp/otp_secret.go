@@ -2,11 +2,27 @@ package otp
 
 import (
 	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
 
 	cryptorand "github.com/cjlapao/common-go-cryptorand"
+	"github.com/cjlapao/common-go-identity-otp/common"
 	"github.com/cjlapao/common-go-identity-otp/helpers"
 )
 
+const (
+	// MinSecretBytes is the shortest secret accepted by the decoding
+	// constructors, 80 bits as used by most authenticator apps.
+	MinSecretBytes = 10
+	// MaxSecretBytes is the longest secret accepted by the decoding
+	// constructors, longer HMAC keys are hashed down anyway.
+	MaxSecretBytes = 128
+)
+
 var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
 
 type OtpSecret struct {
@@ -14,6 +30,8 @@ type OtpSecret struct {
 	value      string
 }
 
+// NewSecret wraps the secret as is, it is not checked against the policy,
+// use NewSecretWithPolicy for secrets that come from users.
 func NewSecret(secret string) *OtpSecret {
 	result := OtpSecret{}
 	result.value = helpers.PadSecret(secret)
@@ -22,11 +40,96 @@ func NewSecret(secret string) *OtpSecret {
 	return &result
 }
 
+// NewSecretWithPolicy works like NewSecret but rejects a secret that is not
+// valid base32 or is shorter than the policy allows, nil uses DefaultPolicy.
+func NewSecretWithPolicy(secret string, policy *Policy) (*OtpSecret, error) {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+
+	if err := validateSecret(secret); err != nil {
+		return nil, err
+	}
+
+	if err := policy.CheckSecret(secret); err != nil {
+		return nil, err
+	}
+
+	return NewSecret(secret), nil
+}
+
+// NewSecretFromBytes checks the length of the secret against MinSecretBytes,
+// MaxSecretBytes and the default policy.
+func NewSecretFromBytes(secret []byte) (*OtpSecret, error) {
+	if len(secret) < MinSecretBytes || len(secret) > MaxSecretBytes {
+		return nil, common.ErrorInvalidSecret.WithField("secret").WithCause(
+			fmt.Errorf("secret is %d bytes, expected between %d and %d", len(secret), MinSecretBytes, MaxSecretBytes))
+	}
+
+	if err := DefaultPolicy().CheckSecretSize(uint(len(secret))); err != nil {
+		return nil, err
+	}
+
+	result := OtpSecret{
+		SecretSize: uint(len(secret)),
+		value:      b32NoPadding.EncodeToString(secret),
+	}
+
+	return &result, nil
+}
+
+// NewSecretFromBase32 accepts lower case, spaces, dashes and missing padding.
+func NewSecretFromBase32(secret string) (*OtpSecret, error) {
+	secretBytes, err := decodeSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSecretFromBytes(secretBytes)
+}
+
+// NewSecretFromHex accepts upper or lower case with spaces, dashes or colons
+// between the bytes, as hardware token seeds are usually shipped.
+func NewSecretFromHex(secret string) (*OtpSecret, error) {
+	secret = strings.TrimPrefix(stripSeparators(secret, ':', '-'), "0x")
+
+	secretBytes, err := hex.DecodeString(secret)
+	if err != nil {
+		return nil, common.ErrorInvalidSecret.WithField("secret").WithCause(err)
+	}
+
+	return NewSecretFromBytes(secretBytes)
+}
+
+// NewSecretFromBase64 accepts the standard and url alphabets, with or without
+// padding.
+func NewSecretFromBase64(secret string) (*OtpSecret, error) {
+	secret = strings.TrimRight(stripSeparators(secret), "=")
+
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(secret, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+
+	secretBytes, err := encoding.DecodeString(secret)
+	if err != nil {
+		return nil, common.ErrorInvalidSecret.WithField("secret").WithCause(err)
+	}
+
+	return NewSecretFromBytes(secretBytes)
+}
+
+// NewRandomOtpSecret returns a secret of size bytes, raised to the minimum
+// size of the default policy.
 func NewRandomOtpSecret(size int) *OtpSecret {
 	if size <= 0 {
 		size = 10
 	}
 
+	if minSize := int(DefaultPolicy().MinSecretSize); size < minSize {
+		size = minSize
+	}
+
 	rand := cryptorand.New().Rand
 	secret := make([]byte, size)
 	rand.Read(secret)
@@ -42,3 +145,66 @@ func NewRandomOtpSecret(size int) *OtpSecret {
 func (s *OtpSecret) Value() string {
 	return s.value
 }
+
+func (s *OtpSecret) Bytes() ([]byte, error) {
+	return decodeSecret(s.value)
+}
+
+// Base32 returns the secret upper cased and without padding, the form most
+// authenticator apps expect.
+func (s *OtpSecret) Base32() (string, error) {
+	secretBytes, err := s.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return b32NoPadding.EncodeToString(secretBytes), nil
+}
+
+func (s *OtpSecret) Hex() (string, error) {
+	secretBytes, err := s.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secretBytes), nil
+}
+
+func (s *OtpSecret) Base64() (string, error) {
+	secretBytes, err := s.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(secretBytes), nil
+}
+
+// LogValue keeps the secret out of the logs.
+func (s *OtpSecret) LogValue() slog.Value {
+	return slog.StringValue(RedactedValue)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secretBytes, err := base32.StdEncoding.DecodeString(helpers.PadSecret(secret))
+	if err != nil {
+		return nil, common.ErrorInvalidSecret.WithField("secret").WithCause(err)
+	}
+
+	return secretBytes, nil
+}
+
+func stripSeparators(secret string, separators ...rune) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+
+		for _, separator := range separators {
+			if r == separator {
+				return -1
+			}
+		}
+
+		return r
+	}, secret)
+}
@@ -1,9 +1,12 @@
 package otp
 
 import (
+	"encoding/base64"
 	"testing"
 
+	"github.com/cjlapao/common-go-identity-otp/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRandomSecret(t *testing.T) {
@@ -12,3 +15,89 @@ func TestNewRandomSecret(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, uint(10), result.SecretSize)
 }
+
+func TestNewSecretFromEncodings(t *testing.T) {
+	seed := []byte("12345678901234567890")
+
+	tests := []struct {
+		name   string
+		create func() (*OtpSecret, error)
+	}{
+		{"bytes", func() (*OtpSecret, error) { return NewSecretFromBytes(seed) }},
+		{"base32", func() (*OtpSecret, error) { return NewSecretFromBase32("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ") }},
+		{"base32 lenient", func() (*OtpSecret, error) { return NewSecretFromBase32("gezd-gnbv gy3t-qojq gezd-gnbv gy3t-qojq") }},
+		{"base32 padded", func() (*OtpSecret, error) { return NewSecretFromBase32("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ====") }},
+		{"hex", func() (*OtpSecret, error) { return NewSecretFromHex("3132333435363738393031323334353637383930") }},
+		{"hex lenient", func() (*OtpSecret, error) {
+			return NewSecretFromHex("31:32:33:34:35 36-37-38-39-30 3132333435363738393 0")
+		}},
+		{"hex prefix", func() (*OtpSecret, error) { return NewSecretFromHex("0x3132333435363738393031323334353637383930") }},
+		{"base64", func() (*OtpSecret, error) { return NewSecretFromBase64("MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=") }},
+		{"base64 raw", func() (*OtpSecret, error) { return NewSecretFromBase64("MTIzNDU2Nzg5MDEy MzQ1Njc4OTA") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := tt.create()
+			require.NoError(t, err)
+
+			secretBytes, err := secret.Bytes()
+			require.NoError(t, err)
+			assert.Equal(t, seed, secretBytes)
+			assert.Equal(t, uint(20), secret.SecretSize)
+			assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", secret.Value())
+		})
+	}
+}
+
+func TestNewSecretFromBase64UrlAlphabet(t *testing.T) {
+	seed := []byte{0xfb, 0xff, 0xbf, 0xfb, 0xff, 0xbf, 0xfb, 0xff, 0xbf, 0xfb, 0xff, 0xbf}
+
+	secret, err := NewSecretFromBase64(base64.URLEncoding.EncodeToString(seed))
+	require.NoError(t, err)
+
+	secretBytes, err := secret.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, seed, secretBytes)
+}
+
+func TestNewSecretFromEncodingsErrors(t *testing.T) {
+	_, err := NewSecretFromBytes(make([]byte, MinSecretBytes-1))
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+	assert.ErrorContains(t, err, "secret is 9 bytes, expected between 10 and 128")
+
+	_, err = NewSecretFromBytes(make([]byte, MaxSecretBytes+1))
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+
+	_, err = NewSecretFromHex("zz313233343536373839")
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+
+	_, err = NewSecretFromBase32("GEZDGNBV1")
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+
+	_, err = NewSecretFromBase64("MTIz*DU2Nzg5MDEyMzQ1Njc4OTA")
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+
+	useStrictPolicy(t)
+	_, err = NewSecretFromBytes(make([]byte, 10))
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+}
+
+func TestSecretExports(t *testing.T) {
+	secret := NewSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+
+	value, err := secret.Base32()
+	require.NoError(t, err)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", value)
+
+	value, err = secret.Hex()
+	require.NoError(t, err)
+	assert.Equal(t, "3132333435363738393031323334353637383930", value)
+
+	value, err = secret.Base64()
+	require.NoError(t, err)
+	assert.Equal(t, "MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=", value)
+
+	_, err = NewSecret("1!1").Bytes()
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+}
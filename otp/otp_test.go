@@ -56,7 +56,6 @@ func TestGenerateWithEmptyOptions(t *testing.T) {
 }
 
 func TestGenerateWithInvalidCodeSize(t *testing.T) {
-	ToggleDebug()
 	for _, entry := range rfcTestMatrix {
 		code, err := GenerateCode(entry.Secret, entry.Counter, &OtpOptions{
 			CodeSize:  0,
@@ -94,17 +93,36 @@ func TestValidateWithNilOptions(t *testing.T) {
 	}
 }
 
+func TestBoundCodes(t *testing.T) {
+	login := &OtpOptions{Binding: "login"}
+	code, err := GenerateCode(sha1Secret, 0, login)
+	require.NoError(t, err)
+	assert.NotEqual(t, rfcTestMatrix[0].Code, code)
+
+	valid, err := ValidateCode(code, 0, sha1Secret, login)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = ValidateCode(code, 0, sha1Secret, &OtpOptions{Binding: "change-email"})
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = ValidateCode(rfcTestMatrix[0].Code, 0, sha1Secret, login)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
 func TestValidateWithInvalidSecretSize(t *testing.T) {
 	code, err := ValidateCode("12345678", rfcTestMatrix[0].Counter, rfcTestMatrix[0].Secret, NewDefaultOtpOptions())
 
-	assert.Equal(t, common.ErrorWrongCodeSize, err)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
 	assert.False(t, code)
 }
 
 func TestValidateWithInvalidOptions(t *testing.T) {
 	code, err := ValidateCode("123456", rfcTestMatrix[0].Counter, "%30 ", NewDefaultOtpOptions())
 
-	assert.Equal(t, common.ErrorInvalidSecret, err)
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
 	assert.False(t, code)
 }
 
@@ -142,7 +160,7 @@ func TestGenerateKey(t *testing.T) {
 		UserId: "foobar@example.com",
 	})
 
-	assert.Equal(t, common.ErrorEmptyIssuer, err)
+	assert.ErrorIs(t, err, common.ErrorEmptyIssuer)
 
 	// empty userid
 	_, err = GenerateKey("SHA1", &OtpKeyOptions{
@@ -150,7 +168,7 @@ func TestGenerateKey(t *testing.T) {
 		UserId: "",
 	})
 
-	assert.Equal(t, common.ErrorEmptyUserID, err)
+	assert.ErrorIs(t, err, common.ErrorEmptyUserID)
 
 	// big secrets
 	k, err = GenerateKey("SHA1", &OtpKeyOptions{
@@ -176,6 +194,175 @@ func TestGenerateKey(t *testing.T) {
 func TestGenerateKeyWithNilOptions(t *testing.T) {
 	k, err := GenerateKey("SHA1", nil)
 
-	assert.Equal(t, common.ErrorNilOtpKeyOptions, err)
+	assert.ErrorIs(t, err, common.ErrorNilOtpKeyOptions)
 	assert.Nil(t, k)
 }
+
+func TestGenerateCodeWithCustomCodeSizes(t *testing.T) {
+	secret := rfcTestMatrix[0].Secret
+	tests := []struct {
+		codeSize common.PassCodeSize
+		counter  uint64
+		want     string
+	}{
+		{common.FourDigits, 0, "5224"},
+		{common.FiveDigits, 0, "55224"},
+		{common.NineDigits, 0, "284755224"},
+		{common.TenDigits, 0, "1284755224"},
+		{common.TenDigits, 1, "1094287082"},
+	}
+	for _, tt := range tests {
+		code, err := GenerateCode(secret, tt.counter, &OtpOptions{
+			CodeSize:  tt.codeSize,
+			Algorithm: common.SHA1Algorithm,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, code)
+
+		valid, err := ValidateCode(code, tt.counter, secret, &OtpOptions{
+			CodeSize:  tt.codeSize,
+			Algorithm: common.SHA1Algorithm,
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestGenerateCodeWithUnsupportedCodeSize(t *testing.T) {
+	code, err := GenerateCode(rfcTestMatrix[0].Secret, 0, &OtpOptions{CodeSize: 11})
+
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+	assert.Equal(t, "", code)
+
+	valid, err := ValidateCode("123", 0, rfcTestMatrix[0].Secret, &OtpOptions{CodeSize: 3})
+
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+	assert.False(t, valid)
+}
+
+func TestNewOtpOptions(t *testing.T) {
+	options, err := NewOtpOptions(common.NineDigits, common.SHA256Algorithm)
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.NineDigits, options.CodeSize)
+	assert.Equal(t, common.SHA256Algorithm, options.Algorithm)
+
+	options, err = NewOtpOptions(common.PassCodeSize(12), common.SHA1Algorithm)
+
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+	assert.Nil(t, options)
+}
+
+func TestGenerateKeyWithCustomCodeSize(t *testing.T) {
+	k, err := GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &OtpOptions{CodeSize: common.TenDigits},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, k.String(), "digits=10")
+
+	digits, err := k.Digits()
+	assert.NoError(t, err)
+	assert.Equal(t, common.TenDigits, digits)
+
+	_, err = GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &OtpOptions{CodeSize: common.PassCodeSize(2)},
+	})
+
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+}
+
+// the SHA-3 vectors use the RFC 4226 secret and were computed with
+// Python's hmac and hashlib modules
+func TestGenerateCodeWithSHA3(t *testing.T) {
+	vectors := map[common.Algorithm][]string{
+		common.SHA3_256Algorithm: {"170828", "902588", "810314", "848987", "384821", "243804", "545314", "717116", "040202", "519923"},
+		common.SHA3_512Algorithm: {"342230", "625483", "819892", "919174", "115067", "925537", "902057", "079366", "307759", "433164"},
+	}
+
+	for algorithm, codes := range vectors {
+		options := &OtpOptions{
+			CodeSize:  common.SixDigits,
+			Algorithm: algorithm,
+		}
+
+		for counter, expected := range codes {
+			code, err := GenerateCode(rfcTestMatrix[0].Secret, uint64(counter), options)
+			require.NoError(t, err)
+			assert.Equal(t, expected, code, "%s counter %d", algorithm, counter)
+
+			valid, err := ValidateCode(expected, uint64(counter), rfcTestMatrix[0].Secret, options)
+			require.NoError(t, err)
+			assert.True(t, valid)
+		}
+	}
+}
+
+func TestGenerateCodeWithUnknownAlgorithm(t *testing.T) {
+	code, err := GenerateCode(rfcTestMatrix[0].Secret, 1, &OtpOptions{
+		CodeSize:  common.SixDigits,
+		Algorithm: common.Algorithm(9999),
+	})
+
+	assert.ErrorIs(t, err, common.ErrorUnknownAlgorithm)
+	assert.Equal(t, "", code)
+
+	_, err = GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &OtpOptions{Algorithm: common.Algorithm(9999)},
+	})
+
+	assert.ErrorIs(t, err, common.ErrorUnknownAlgorithm)
+}
+
+func TestGenerateKeyAlgorithmRoundTrip(t *testing.T) {
+	k, err := GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &OtpOptions{Algorithm: common.SHA3_512Algorithm},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, k.String(), "algorithm=SHA3-512")
+
+	algorithm, err := k.HashAlgorithm()
+	require.NoError(t, err)
+	assert.Equal(t, common.SHA3_512Algorithm, algorithm)
+}
+
+func TestErrorsCarryFieldAndCause(t *testing.T) {
+	_, err := GenerateCode("%30 ", 1, NewDefaultOtpOptions())
+
+	var otpError *common.OtpError
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, common.InvalidSecretErrorCode, otpError.Code)
+	assert.Equal(t, "secret", otpError.Field)
+
+	var corruptInput base32.CorruptInputError
+	assert.ErrorAs(t, err, &corruptInput)
+
+	_, err = GenerateKey("totp", &OtpKeyOptions{UserId: "foobar@example.com"})
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, common.EmptyIssuerErrorCode, otpError.Code)
+	assert.Equal(t, "Issuer", otpError.Field)
+	assert.Contains(t, otpError.Err.Error(), "Issuer")
+
+	_, err = GenerateKey("totp", &OtpKeyOptions{
+		Issuer:  "foobar",
+		UserId:  "foobar@example.com",
+		Options: &OtpOptions{CodeSize: 12},
+	})
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, "Options.CodeSize", otpError.Field)
+
+	_, err = ValidateCode("1234", 1, rfcTestMatrix[0].Secret, NewDefaultOtpOptions())
+	require.ErrorAs(t, err, &otpError)
+	assert.Equal(t, common.WrongCodeSizeErrorCode, otpError.Code)
+	assert.Equal(t, "code", otpError.Field)
+}
@@ -1,29 +1,64 @@
 package totp
 
 import (
-	"math"
+	"context"
+	"errors"
+	"log/slog"
 	"time"
 
+	"github.com/cjlapao/common-go-identity-otp/common"
 	"github.com/cjlapao/common-go-identity-otp/otp"
 )
 
 func GenerateCode(secret string, t time.Time, options *TotpOptions) (string, error) {
+	return GenerateCodeContext(context.Background(), secret, t, options)
+}
+
+// GenerateCodeContext works like GenerateCode, it fails if ctx is done and
+// reports to the logger and observer carried by ctx.
+func GenerateCodeContext(ctx context.Context, secret string, t time.Time, options *TotpOptions) (string, error) {
 	if options == nil {
 		options = NewDefaultTotpOptions()
 	}
 
+	start := time.Now()
+	code, err := generateCode(ctx, secret, t, options)
+	otp.Notify(ctx, nil, otp.Event{
+		Kind:      otp.GenerateEvent,
+		Type:      "totp",
+		Outcome:   otp.OutcomeOf(err == nil, err),
+		Algorithm: options.Algorithm.String(),
+		Err:       err,
+	}, start)
+
+	return code, err
+}
+
+func generateCode(ctx context.Context, secret string, t time.Time, options *TotpOptions) (string, error) {
 	if options.Period == 0 {
 		options.Period = 30
 	}
 
-	counter := getTimeCounter(options.Period, t)
+	counter, err := getTimeCounter(options.Period, options.T0, t)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
-	otpOptions := otp.OtpOptions{
-		CodeSize:  options.CodeSize,
-		Algorithm: options.Algorithm,
+	// the generator is used and not otp.GenerateCodeContext, so only the
+	// totp event is sent to the observer
+	generator, err := otp.NewGenerator(secret, options.otpOptions())
+	if err != nil {
+		return "", err
 	}
 
-	return otp.GenerateCode(secret, counter, &otpOptions)
+	code := generator.Generate(counter)
+	otp.LoggerFromContext(ctx).Debug("totp code generated", slog.Uint64("counter", counter))
+
+	return code, nil
 }
 
 func GenerateDefault(secret string) (string, error) {
@@ -31,35 +66,50 @@ func GenerateDefault(secret string) (string, error) {
 }
 
 func Validate(code string, secret string, t time.Time, options *TotpOptions) (bool, error) {
-	if options.Period == 0 {
-		options.Period = 30
-	}
+	return ValidateContext(context.Background(), code, secret, t, options)
+}
 
-	counters := []uint64{}
-	counter := getTimeCounter(options.Period, t)
+// ValidateContext works like Validate, it fails if ctx is done and notifies
+// the observer carried by ctx. The options and secret are checked against
+// otp.DefaultPolicy.
+func ValidateContext(ctx context.Context, code string, secret string, t time.Time, options *TotpOptions) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
 
-	counters = append(counters, counter)
-	for i := 1; i <= int(options.Skew); i++ {
-		counters = append(counters, uint64(counter+uint64(i)))
-		counters = append(counters, uint64(counter-uint64(i)))
+	if options == nil {
+		options = NewDefaultTotpOptions()
 	}
 
-	for _, counter := range counters {
-		result, err := otp.ValidateCode(code, counter, secret, &otp.OtpOptions{
-			CodeSize:  options.CodeSize,
-			Algorithm: options.Algorithm,
-		})
+	if options.Period == 0 {
+		options.Period = 30
+	}
 
-		if err != nil {
-			return false, err
-		}
+	counter, err := getTimeCounter(options.Period, options.T0, t)
+	if err != nil {
+		return false, err
+	}
 
-		if result {
-			return true, nil
-		}
+	start := time.Now()
+	var offset int64
+	var valid bool
+	policy := otp.DefaultPolicy()
+	err = errors.Join(options.CheckPolicy(policy), policy.CheckSecret(secret))
+	if err == nil {
+		past, future := options.window()
+		offset, valid, err = validateWindow(code, secret, counter, past, future, options, nil)
 	}
 
-	return false, nil
+	otp.Notify(ctx, nil, otp.Event{
+		Kind:      otp.ValidateEvent,
+		Type:      "totp",
+		Outcome:   otp.OutcomeOf(valid, err),
+		Algorithm: options.Algorithm.String(),
+		Drift:     offset,
+		Err:       err,
+	}, start)
+
+	return valid, err
 }
 
 func ValidateDefault(code string, secret string) (bool, error) {
@@ -70,6 +120,10 @@ func GenerateKey(opts *otp.OtpKeyOptions) (*otp.OtpKey, error) {
 	return otp.GenerateKey("totp", opts)
 }
 
+func GenerateKeyContext(ctx context.Context, opts *otp.OtpKeyOptions) (*otp.OtpKey, error) {
+	return otp.GenerateKeyContext(ctx, "totp", opts)
+}
+
 func GenerateDefaultKey(issuer, userId string) (*otp.OtpKey, error) {
 	options := otp.OtpKeyOptions{
 		Issuer:  issuer,
@@ -81,8 +135,27 @@ func GenerateDefaultKey(issuer, userId string) (*otp.OtpKey, error) {
 	return otp.GenerateKey("totp", &options)
 }
 
-func getTimeCounter(period uint, t time.Time) uint64 {
-	counter := uint64(math.Floor(float64(t.Unix()) / float64(period)))
+// validateWindow decodes the secret once and checks the window around the
+// counter, see otp.Generator.ValidateWindow for the order steps are tried in.
+// A nil normalizer uses the default one.
+func validateWindow(code string, secret string, counter uint64, past uint, future uint, options *TotpOptions, normalizer *otp.CodeNormalizer) (int64, bool, error) {
+	generator, err := otp.NewGenerator(secret, options.otpOptions())
+	if err != nil {
+		return 0, false, err
+	}
+	generator.SetNormalizer(normalizer)
+
+	return generator.ValidateWindow(code, counter, past, future)
+}
+
+func getTimeCounter(period uint, t0 int64, t time.Time) (uint64, error) {
+	unix := t.Unix()
+	if unix < t0 {
+		return 0, common.ErrorTimeBeforeEpoch.WithField("t")
+	}
+
+	// the difference always fits in an uint64 even when it overflows an int64
+	elapsed := uint64(unix) - uint64(t0)
 
-	return counter
+	return elapsed / uint64(period), nil
 }
@@ -0,0 +1,140 @@
+package totp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+)
+
+// Options without a binding keep the version 1 layout, version 2 prefixes
+// the algorithm with its length and appends the binding.
+const (
+	optionsBinaryVersion      byte = 1
+	boundOptionsBinaryVersion byte = 2
+)
+
+// MarshalJSON encodes the zero Period and CodeSize as the defaults they
+// stand for.
+func (o TotpOptions) MarshalJSON() ([]byte, error) {
+	type plain TotpOptions
+	options := o.withDefaults()
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(plain(options))
+}
+
+// UnmarshalJSON rejects unknown fields and invalid values, missing fields
+// keep their defaults.
+func (o *TotpOptions) UnmarshalJSON(data []byte) error {
+	type plain TotpOptions
+	result := plain(*NewDefaultTotpOptions())
+
+	if err := common.DecodeJSONStrict(data, &result); err != nil {
+		return err
+	}
+
+	options := TotpOptions(result)
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	*o = options
+
+	return nil
+}
+
+func (o *TotpOptions) MarshalBinary() ([]byte, error) {
+	options := o.withDefaults()
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	algorithm, err := options.Algorithm.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	version := optionsBinaryVersion
+	if options.Binding != "" {
+		version = boundOptionsBinaryVersion
+	}
+
+	result := []byte{version}
+	result = binary.AppendVarint(result, options.T0)
+	for _, value := range []uint{options.Period, options.Skew, options.PastSkew, options.FutureSkew} {
+		result = binary.AppendUvarint(result, uint64(value))
+	}
+	result = append(result, byte(options.CodeSize))
+
+	if version == optionsBinaryVersion {
+		return append(result, algorithm...), nil
+	}
+
+	result = append(result, byte(len(algorithm)))
+	result = append(result, algorithm...)
+
+	return append(result, options.Binding...), nil
+}
+
+func (o *TotpOptions) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || (data[0] != optionsBinaryVersion && data[0] != boundOptionsBinaryVersion) {
+		return common.ErrorInvalidEncoding.WithField("TotpOptions")
+	}
+	version := data[0]
+	data = data[1:]
+
+	var result TotpOptions
+	t0, n := binary.Varint(data)
+	if n <= 0 {
+		return common.ErrorInvalidEncoding.WithField("T0")
+	}
+	result.T0 = t0
+	data = data[n:]
+
+	fields := []struct {
+		name  string
+		value *uint
+	}{
+		{"Period", &result.Period},
+		{"Skew", &result.Skew},
+		{"PastSkew", &result.PastSkew},
+		{"FutureSkew", &result.FutureSkew},
+	}
+	for _, field := range fields {
+		value, n := binary.Uvarint(data)
+		if n <= 0 || uint64(uint(value)) != value {
+			return common.ErrorInvalidEncoding.WithField(field.name)
+		}
+		*field.value = uint(value)
+		data = data[n:]
+	}
+
+	if len(data) < 2 {
+		return common.ErrorInvalidEncoding.WithField("TotpOptions")
+	}
+
+	if err := result.CodeSize.UnmarshalBinary(data[:1]); err != nil {
+		return common.WithField(err, "CodeSize")
+	}
+
+	algorithm := data[1:]
+	if version == boundOptionsBinaryVersion {
+		size := int(data[1])
+		if size == 0 || len(data) <= 2+size {
+			return common.ErrorInvalidEncoding.WithField("Binding")
+		}
+		algorithm = data[2 : 2+size]
+		result.Binding = string(data[2+size:])
+	}
+
+	if err := result.Algorithm.UnmarshalBinary(algorithm); err != nil {
+		return common.WithField(err, "Algorithm")
+	}
+
+	*o = result
+
+	return nil
+}
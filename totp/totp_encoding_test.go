@@ -0,0 +1,77 @@
+package totp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTotpOptionsJSON(t *testing.T) {
+	options := &TotpOptions{
+		T0:         -60,
+		Period:     60,
+		Skew:       1,
+		PastSkew:   2,
+		FutureSkew: 0,
+		CodeSize:   common.EightDigits,
+		Algorithm:  common.SHA512Algorithm,
+	}
+
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"t0":-60,"period":60,"skew":1,"past_skew":2,"future_skew":0,"digits":8,"algorithm":"SHA512"}`, string(data))
+
+	var decoded TotpOptions
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *options, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"digits":7}`), &decoded))
+	expected := NewDefaultTotpOptions()
+	expected.CodeSize = common.SevenDigits
+	assert.Equal(t, *expected, decoded)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"counter":1}`), &decoded), common.ErrorInvalidEncoding)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"period":-1}`), &decoded), common.ErrorInvalidEncoding)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"digits":"6"}`), &decoded), common.ErrorInvalidCodeSize)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"algorithm":"SHA224"}`), &decoded), common.ErrorUnknownAlgorithm)
+}
+
+func TestTotpOptionsBinary(t *testing.T) {
+	options := &TotpOptions{
+		T0:         1234567890,
+		Period:     30,
+		Skew:       1,
+		PastSkew:   3,
+		FutureSkew: 1,
+		CodeSize:   common.SixDigits,
+		Algorithm:  common.SHA3_512Algorithm,
+	}
+
+	data, err := options.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded TotpOptions
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, *options, decoded)
+
+	for i := 0; i < len(data)-1; i++ {
+		assert.Error(t, decoded.UnmarshalBinary(data[:i]), i)
+	}
+
+	data, err = (&TotpOptions{}).MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, TotpOptions{Period: 30, CodeSize: common.SixDigits}, decoded)
+
+	bound := &TotpOptions{Period: 60, CodeSize: common.EightDigits, Algorithm: common.SHA256Algorithm, Binding: "login"}
+	data, err = bound.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, *bound, decoded)
+
+	_, err = (&TotpOptions{CodeSize: common.PassCodeSize(12)}).MarshalBinary()
+	assert.ErrorIs(t, err, common.ErrorInvalidCodeSize)
+}
@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+// Generator computes and validates the codes of a single secret without
+// decoding it again, it is meant to be kept around by services validating
+// many codes, observers are not notified.
+type Generator struct {
+	options   TotpOptions
+	generator *otp.Generator
+}
+
+func NewGenerator(secret string, options *TotpOptions) (*Generator, error) {
+	if options == nil {
+		options = NewDefaultTotpOptions()
+	}
+
+	result := Generator{
+		options: options.withDefaults(),
+	}
+
+	generator, err := otp.NewGenerator(secret, result.options.otpOptions())
+	if err != nil {
+		return nil, err
+	}
+	result.generator = generator
+
+	return &result, nil
+}
+
+// SetNormalizer sets the normalizer applied to the validated codes, nil uses
+// the default one. It must be set before the generator is shared.
+func (g *Generator) SetNormalizer(normalizer *otp.CodeNormalizer) {
+	g.generator.SetNormalizer(normalizer)
+}
+
+func (g *Generator) Generate(t time.Time) (string, error) {
+	counter, err := getTimeCounter(g.options.Period, g.options.T0, t)
+	if err != nil {
+		return "", err
+	}
+
+	return g.generator.Generate(counter), nil
+}
+
+// AppendCode appends the code for t to dst.
+func (g *Generator) AppendCode(dst []byte, t time.Time) ([]byte, error) {
+	counter, err := getTimeCounter(g.options.Period, g.options.T0, t)
+	if err != nil {
+		return dst, err
+	}
+
+	return g.generator.AppendCode(dst, counter), nil
+}
+
+func (g *Generator) Validate(code string, t time.Time) (bool, error) {
+	_, valid, err := g.ValidateStep(code, t)
+
+	return valid, err
+}
+
+// ValidateStep checks the code in the skew window around t and returns the
+// time step it matched.
+func (g *Generator) ValidateStep(code string, t time.Time) (uint64, bool, error) {
+	counter, err := getTimeCounter(g.options.Period, g.options.T0, t)
+	if err != nil {
+		return 0, false, err
+	}
+
+	past, future := g.options.window()
+	offset, valid, err := g.generator.ValidateWindow(code, counter, past, future)
+	if err != nil || !valid {
+		return 0, false, err
+	}
+
+	return uint64(int64(counter) + offset), true, nil
+}
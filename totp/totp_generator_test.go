@@ -0,0 +1,75 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorRFCMatrix(t *testing.T) {
+	for _, entry := range rfcTestMatrix {
+		generator, err := NewGenerator(entry.Secret, &TotpOptions{
+			CodeSize:  common.EightDigits,
+			Skew:      1,
+			Algorithm: entry.Mode,
+		})
+		require.NoError(t, err)
+
+		now := time.Unix(int64(entry.Counter), 0).UTC()
+		code, err := generator.Generate(now)
+		require.NoError(t, err)
+		assert.Equal(t, entry.Code, code)
+
+		step, valid, err := generator.ValidateStep(entry.Code, now.Add(30*time.Second))
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, entry.Counter/30, step)
+	}
+}
+
+func TestGeneratorMatchesValidate(t *testing.T) {
+	options := &TotpOptions{T0: 1000, Period: 60, PastSkew: 2, FutureSkew: 1, CodeSize: common.SevenDigits, Binding: "login"}
+	generator, err := NewGenerator(sha1Secret, options)
+	require.NoError(t, err)
+
+	now := time.Unix(1234567890, 0).UTC()
+	for offset := -3; offset <= 2; offset++ {
+		code, err := GenerateCode(sha1Secret, now.Add(time.Duration(offset)*time.Minute), options)
+		require.NoError(t, err)
+
+		expected, err := Validate(code, sha1Secret, now, options)
+		require.NoError(t, err)
+
+		valid, err := generator.Validate(code, now)
+		require.NoError(t, err)
+		assert.Equal(t, expected, valid, offset)
+	}
+
+	_, err = generator.Generate(time.Unix(999, 0))
+	assert.ErrorIs(t, err, common.ErrorTimeBeforeEpoch)
+}
+
+func BenchmarkValidate(b *testing.B) {
+	options := NewDefaultTotpOptions()
+	now := time.Now().UTC()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = Validate("000000", sha1Secret, now, options)
+	}
+}
+
+func BenchmarkGeneratorValidate(b *testing.B) {
+	generator, err := NewGenerator(sha1Secret, nil)
+	require.NoError(b, err)
+	now := time.Now().UTC()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = generator.Validate("000000", now)
+	}
+}
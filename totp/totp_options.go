@@ -1,12 +1,26 @@
 package totp
 
-import "github.com/cjlapao/common-go-identity-otp/common"
+import (
+	"errors"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
 
 type TotpOptions struct {
-	Period    uint
-	Skew      uint
-	CodeSize  common.PassCodeSize
-	Algorithm common.Algorithm
+	// T0 is the Unix time, in seconds, to start counting time steps from,
+	// RFC 6238 uses 0 for the Unix epoch.
+	T0     int64 `json:"t0"`
+	Period uint  `json:"period"`
+	Skew   uint  `json:"skew"`
+	// PastSkew and FutureSkew set how many steps behind and ahead of the
+	// current one are accepted, when both are zero Skew is used for both.
+	PastSkew   uint                `json:"past_skew"`
+	FutureSkew uint                `json:"future_skew"`
+	CodeSize   common.PassCodeSize `json:"digits"`
+	Algorithm  common.Algorithm    `json:"algorithm"`
+	// Binding ties codes to a context, see otp.OtpOptions.Binding.
+	Binding string `json:"binding,omitempty"`
 }
 
 func NewDefaultTotpOptions() *TotpOptions {
@@ -19,3 +33,58 @@ func NewDefaultTotpOptions() *TotpOptions {
 
 	return &result
 }
+
+func (o *TotpOptions) Validate() error {
+	if err := o.CodeSize.Validate(); err != nil {
+		return common.WithField(err, "CodeSize")
+	}
+
+	if err := o.Algorithm.Validate(); err != nil {
+		return common.WithField(err, "Algorithm")
+	}
+
+	return nil
+}
+
+// CheckPolicy returns all the policy violations of the options joined in a
+// single error.
+func (o *TotpOptions) CheckPolicy(policy *otp.Policy) error {
+	options := o.withDefaults()
+	past, future := options.window()
+
+	return errors.Join(
+		policy.CheckOptions(&otp.OtpOptions{CodeSize: options.CodeSize, Algorithm: options.Algorithm}),
+		policy.CheckPeriod(options.Period),
+		policy.CheckSkew(past, future),
+	)
+}
+
+func (o TotpOptions) withDefaults() TotpOptions {
+	if o.Period == 0 {
+		o.Period = 30
+	}
+
+	if o.CodeSize == 0 {
+		o.CodeSize = common.SixDigits
+	}
+
+	return o
+}
+
+func (o *TotpOptions) otpOptions() *otp.OtpOptions {
+	result := otp.OtpOptions{
+		CodeSize:  o.CodeSize,
+		Algorithm: o.Algorithm,
+		Binding:   o.Binding,
+	}
+
+	return &result
+}
+
+func (o *TotpOptions) window() (uint, uint) {
+	if o.PastSkew == 0 && o.FutureSkew == 0 {
+		return o.Skew, o.Skew
+	}
+
+	return o.PastSkew, o.FutureSkew
+}
@@ -2,6 +2,7 @@ package totp
 
 import (
 	"encoding/base32"
+	"math"
 	"net/url"
 	"testing"
 	"time"
@@ -128,7 +129,7 @@ func TestValidateWithInvalidCodeException(t *testing.T) {
 			Algorithm: common.SHA1Algorithm,
 		})
 
-	assert.Equal(t, common.ErrorWrongCodeSize, err)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
 	assert.False(t, valid)
 }
 
@@ -146,6 +147,60 @@ func TestGenerateCode(t *testing.T) {
 	}
 }
 
+// the SHA-3 vectors use the RFC 6238 secrets and times and were computed
+// with Python's hmac and hashlib modules
+func TestGenerateCodeWithSHA3(t *testing.T) {
+	vectors := []RfcTestMatrixEntry{
+		{59, "03503818", common.SHA3_256Algorithm, sha256Secret},
+		{59, "01892432", common.SHA3_512Algorithm, sha512Secret},
+		{1111111109, "00384900", common.SHA3_256Algorithm, sha256Secret},
+		{1111111109, "25574199", common.SHA3_512Algorithm, sha512Secret},
+		{1111111111, "32359471", common.SHA3_256Algorithm, sha256Secret},
+		{1111111111, "52419195", common.SHA3_512Algorithm, sha512Secret},
+		{1234567890, "06893637", common.SHA3_256Algorithm, sha256Secret},
+		{1234567890, "84520349", common.SHA3_512Algorithm, sha512Secret},
+		{2000000000, "49355738", common.SHA3_256Algorithm, sha256Secret},
+		{2000000000, "39414928", common.SHA3_512Algorithm, sha512Secret},
+		{20000000000, "23378950", common.SHA3_256Algorithm, sha256Secret},
+		{20000000000, "29527821", common.SHA3_512Algorithm, sha512Secret},
+	}
+
+	for _, entry := range vectors {
+		options := &TotpOptions{
+			CodeSize:  common.EightDigits,
+			Period:    30,
+			Algorithm: entry.Mode,
+		}
+		at := time.Unix(int64(entry.Counter), 0).UTC()
+
+		code, err := GenerateCode(entry.Secret, at, options)
+		require.NoError(t, err)
+		assert.Equal(t, entry.Code, code, "%s at %d", entry.Mode, entry.Counter)
+
+		valid, err := Validate(entry.Code, entry.Secret, at, options)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestBoundCodes(t *testing.T) {
+	options := &TotpOptions{Period: 30, Skew: 1, CodeSize: common.SixDigits, Binding: "login"}
+	now := time.Unix(1234567890, 0).UTC()
+
+	code, err := GenerateCode(sha1Secret, now, options)
+	require.NoError(t, err)
+
+	valid, err := Validate(code, sha1Secret, now, options)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	other := *options
+	other.Binding = "change-email"
+	valid, err = Validate(code, sha1Secret, now, &other)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
 func TestGenerateKey(t *testing.T) {
 	k, err := GenerateKey(&otp.OtpKeyOptions{
 		Issuer: "foobar",
@@ -174,7 +229,7 @@ func TestGenerateKey(t *testing.T) {
 		UserId: "foobar@example.com",
 	})
 
-	assert.Equal(t, common.ErrorEmptyIssuer, err)
+	assert.ErrorIs(t, err, common.ErrorEmptyIssuer)
 
 	// empty userid
 	_, err = GenerateKey(&otp.OtpKeyOptions{
@@ -182,7 +237,7 @@ func TestGenerateKey(t *testing.T) {
 		UserId: "",
 	})
 
-	assert.Equal(t, common.ErrorEmptyUserID, err)
+	assert.ErrorIs(t, err, common.ErrorEmptyUserID)
 
 	// big secrets
 	k, err = GenerateKey(&otp.OtpKeyOptions{
@@ -247,3 +302,163 @@ func TestValidateWithLongCode(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, valid)
 }
+
+func TestGenerateCodeWithEpochOffset(t *testing.T) {
+	t0 := int64(1700000000)
+	for _, entry := range rfcTestMatrix {
+		options := &TotpOptions{
+			T0:        t0,
+			Period:    30,
+			Skew:      0,
+			CodeSize:  common.EightDigits,
+			Algorithm: entry.Mode,
+		}
+		ts := time.Unix(t0+int64(entry.Counter), 0).UTC()
+
+		code, err := GenerateCode(entry.Secret, ts, options)
+		require.NoError(t, err)
+		assert.Equal(t, entry.Code, code)
+
+		valid, err := Validate(entry.Code, entry.Secret, ts, options)
+		require.NoError(t, err)
+		assert.True(t, valid,
+			"unexpected totp failure totp=%s mode=%v ts=%v", entry.Code, entry.Mode, entry.Counter)
+	}
+}
+
+func TestGenerateCodeWithNegativeEpoch(t *testing.T) {
+	options := &TotpOptions{
+		T0:        -3600,
+		Period:    30,
+		CodeSize:  common.EightDigits,
+		Algorithm: common.SHA1Algorithm,
+	}
+
+	code, err := GenerateCode(sha1Secret, time.Unix(-3600+59, 0), options)
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+}
+
+func TestGenerateCodeBeforeEpoch(t *testing.T) {
+	tests := []struct {
+		name string
+		t0   int64
+		ts   time.Time
+	}{
+		{"before unix epoch", 0, time.Unix(-1, 0)},
+		{"before custom epoch", 1000, time.Unix(999, 0)},
+		{"far before epoch", 0, time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &TotpOptions{
+				T0:       tt.t0,
+				Period:   30,
+				CodeSize: common.SixDigits,
+			}
+
+			code, err := GenerateCode(sha1Secret, tt.ts, options)
+			assert.ErrorIs(t, err, common.ErrorTimeBeforeEpoch)
+			assert.Equal(t, "", code)
+
+			valid, err := Validate("123456", sha1Secret, tt.ts, options)
+			assert.ErrorIs(t, err, common.ErrorTimeBeforeEpoch)
+			assert.False(t, valid)
+		})
+	}
+}
+
+func TestGetTimeCounter(t *testing.T) {
+	counter, err := getTimeCounter(30, 0, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), counter)
+
+	counter, err = getTimeCounter(30, 0, time.Unix(60, 999))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), counter)
+
+	counter, err = getTimeCounter(30, 30, time.Unix(30, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), counter)
+}
+
+func TestValidateAsymmetricSkew(t *testing.T) {
+	// 94287082 is the code for the step starting at 30 seconds
+	tests := []struct {
+		name       string
+		ts         int64
+		pastSkew   uint
+		futureSkew uint
+		want       bool
+	}{
+		{"current step", 45, 1, 0, true},
+		{"late code within past skew", 75, 1, 0, true},
+		{"late code outside past skew", 105, 1, 0, false},
+		{"late code within wider past skew", 105, 2, 0, true},
+		{"early code without future skew", 15, 1, 0, false},
+		{"early code within future skew", 15, 0, 1, true},
+		{"late code without past skew", 75, 0, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := Validate("94287082", sha1Secret, time.Unix(tt.ts, 0).UTC(),
+				&TotpOptions{
+					Period:     30,
+					Skew:       5,
+					PastSkew:   tt.pastSkew,
+					FutureSkew: tt.futureSkew,
+					CodeSize:   common.EightDigits,
+					Algorithm:  common.SHA1Algorithm,
+				})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, valid)
+		})
+	}
+}
+
+func TestValidateSkewDoesNotUnderflow(t *testing.T) {
+	options := &TotpOptions{
+		Period:    30,
+		Skew:      2,
+		CodeSize:  common.EightDigits,
+		Algorithm: common.SHA1Algorithm,
+	}
+	wrapped, err := otp.GenerateCode(sha1Secret, math.MaxUint64, &otp.OtpOptions{
+		CodeSize:  common.EightDigits,
+		Algorithm: common.SHA1Algorithm,
+	})
+	require.NoError(t, err)
+
+	valid, err := Validate(wrapped, sha1Secret, time.Unix(0, 0).UTC(), options)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestValidateWindowOrder(t *testing.T) {
+	otpOptions := &otp.OtpOptions{
+		CodeSize:  common.EightDigits,
+		Algorithm: common.SHA1Algorithm,
+	}
+	options := &TotpOptions{
+		CodeSize:  common.EightDigits,
+		Algorithm: common.SHA1Algorithm,
+	}
+
+	for _, counter := range []uint64{8, 9, 10, 11, 12} {
+		code, err := otp.GenerateCode(sha1Secret, counter, otpOptions)
+		require.NoError(t, err)
+
+		offset, valid, err := validateWindow(code, sha1Secret, 10, 2, 2, options, nil)
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, int64(counter)-10, offset)
+	}
+
+	code, err := otp.GenerateCode(sha1Secret, math.MaxUint64, otpOptions)
+	require.NoError(t, err)
+
+	offset, valid, err := validateWindow(code, sha1Secret, math.MaxUint64, 0, 3, options, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, int64(0), offset)
+}
@@ -0,0 +1,160 @@
+package totp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/otp"
+)
+
+const DefaultMaxDrift = 10
+
+// DriftState is the per credential clock drift learned by the Validator, it
+// is meant to be persisted next to the credential secret.
+type DriftState struct {
+	Offset int64 `json:"offset"`
+}
+
+type Validator struct {
+	Options *TotpOptions
+	// MaxDrift is the furthest, in steps, a code can be from the server time
+	// step, it never narrows the skew window set in the options.
+	MaxDrift uint
+	// Policy overrides otp.DefaultPolicy for the options and secrets checked
+	// by this validator.
+	Policy *otp.Policy
+	// Normalizer overrides otp.DefaultCodeNormalizer for the codes checked
+	// by this validator.
+	Normalizer *otp.CodeNormalizer
+	Logger     *slog.Logger
+	Observer   otp.Observer
+}
+
+func NewValidator(options *TotpOptions) *Validator {
+	if options == nil {
+		options = NewDefaultTotpOptions()
+	}
+
+	result := Validator{
+		Options:  options,
+		MaxDrift: DefaultMaxDrift,
+	}
+
+	return &result
+}
+
+// Validate checks the code in a window centered on the server time step
+// shifted by the learned drift, on success the drift is updated with the
+// offset of the matching step.
+func (v *Validator) Validate(code string, secret string, t time.Time, drift *DriftState) (bool, error) {
+	return v.ValidateContext(context.Background(), code, secret, t, drift)
+}
+
+// ValidateContext works like Validate, it fails if ctx is done and reports to
+// the logger and observer carried by ctx when the validator has none.
+func (v *Validator) ValidateContext(ctx context.Context, code string, secret string, t time.Time, drift *DriftState) (bool, error) {
+	_, valid, err := v.ValidateStepContext(ctx, code, secret, t, drift)
+
+	return valid, err
+}
+
+// ValidateStep works like Validate but also returns the time step the code
+// matched, callers use it to reject a code that was already used.
+func (v *Validator) ValidateStep(code string, secret string, t time.Time, drift *DriftState) (uint64, bool, error) {
+	return v.ValidateStepContext(context.Background(), code, secret, t, drift)
+}
+
+func (v *Validator) ValidateStepContext(ctx context.Context, code string, secret string, t time.Time, drift *DriftState) (uint64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+
+	options := v.Options
+	if options == nil {
+		options = NewDefaultTotpOptions()
+	}
+
+	if options.Period == 0 {
+		options.Period = 30
+	}
+
+	if drift == nil {
+		drift = &DriftState{}
+	}
+
+	start := time.Now()
+	step, valid, err := v.validateStep(ctx, code, secret, t, drift, options)
+	otp.Notify(ctx, v.Observer, otp.Event{
+		Kind:      otp.ValidateEvent,
+		Type:      "totp",
+		Outcome:   otp.OutcomeOf(valid, err),
+		Algorithm: options.Algorithm.String(),
+		Drift:     drift.Offset,
+		Err:       err,
+	}, start)
+
+	return step, valid, err
+}
+
+func (v *Validator) validateStep(ctx context.Context, code string, secret string, t time.Time, drift *DriftState, options *TotpOptions) (uint64, bool, error) {
+	policy := v.policy()
+	if err := errors.Join(options.CheckPolicy(policy), policy.CheckSecret(secret)); err != nil {
+		v.logger(ctx).Warn("totp validation rejected by policy", slog.Any("error", err))
+		return 0, false, err
+	}
+
+	counter, err := getTimeCounter(options.Period, options.T0, t)
+	if err != nil {
+		return 0, false, err
+	}
+
+	past, future := options.window()
+	low := -int64(max(v.MaxDrift, past))
+	high := int64(max(v.MaxDrift, future))
+
+	center := min(max(drift.Offset, low), high)
+	if center < 0 && uint64(-center) > counter {
+		center = -int64(counter)
+	}
+
+	pastSteps := uint(center - max(center-int64(past), low))
+	futureSteps := uint(min(center+int64(future), high) - center)
+
+	offset, valid, err := validateWindow(code, secret, counter+uint64(center), pastSteps, futureSteps, options, v.Normalizer)
+	if err != nil {
+		v.logger(ctx).Warn("totp validation error", slog.Any("error", err))
+		return 0, false, err
+	}
+
+	if !valid {
+		v.logger(ctx).Debug("totp code rejected",
+			slog.Uint64("step", counter),
+			slog.Int64("drift", drift.Offset))
+		return 0, false, nil
+	}
+
+	drift.Offset = center + offset
+	v.logger(ctx).Debug("totp code accepted",
+		slog.Uint64("step", counter),
+		slog.Int64("drift", drift.Offset))
+
+	return counter + uint64(drift.Offset), true, nil
+}
+
+func (v *Validator) policy() *otp.Policy {
+	if v.Policy != nil {
+		return v.Policy
+	}
+
+	return otp.DefaultPolicy()
+}
+
+func (v *Validator) logger(ctx context.Context) *slog.Logger {
+	if v.Logger != nil {
+		return v.Logger
+	}
+
+	return otp.LoggerFromContext(ctx)
+}
@@ -0,0 +1,220 @@
+package totp
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func codeAt(t *testing.T, counter uint64) string {
+	code, err := otp.GenerateCode(sha1Secret, counter, &otp.OtpOptions{
+		CodeSize:  common.SixDigits,
+		Algorithm: common.SHA1Algorithm,
+	})
+	require.NoError(t, err)
+
+	return code
+}
+
+func TestValidatorLearnsDrift(t *testing.T) {
+	validator := NewValidator(NewDefaultTotpOptions())
+	validator.MaxDrift = 3
+	drift := DriftState{}
+	now := time.Unix(30*1000, 0).UTC()
+
+	// the phone clock runs ahead, one more step every time
+	for i := uint64(1); i <= 3; i++ {
+		valid, err := validator.Validate(codeAt(t, 1000+i), sha1Secret, now, &drift)
+		require.NoError(t, err)
+		require.True(t, valid, "step offset %v should be accepted", i)
+		assert.Equal(t, int64(i), drift.Offset)
+	}
+
+	// a plain validation does not know about the drift
+	valid, err := Validate(codeAt(t, 1003), sha1Secret, now, NewDefaultTotpOptions())
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	// beyond the maximum drift
+	valid, err = validator.Validate(codeAt(t, 1004), sha1Secret, now, &drift)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, int64(3), drift.Offset)
+
+	// the clock is corrected and the drift moves back towards the server
+	valid, err = validator.Validate(codeAt(t, 1002), sha1Secret, now, &drift)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, int64(2), drift.Offset)
+}
+
+func TestValidatorWithoutDriftState(t *testing.T) {
+	validator := NewValidator(nil)
+	now := time.Unix(30*1000, 0).UTC()
+
+	valid, err := validator.Validate(codeAt(t, 1001), sha1Secret, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = validator.Validate(codeAt(t, 1002), sha1Secret, now, nil)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestValidatorClampsStoredDrift(t *testing.T) {
+	validator := NewValidator(NewDefaultTotpOptions())
+	validator.MaxDrift = 2
+	drift := DriftState{Offset: -50}
+
+	// the stored drift cannot take the window before the first step
+	valid, err := validator.Validate(codeAt(t, 0), sha1Secret, time.Unix(30, 0).UTC(), &drift)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, int64(-1), drift.Offset)
+
+	// nor beyond the maximum drift
+	drift.Offset = 50
+	valid, err = validator.Validate(codeAt(t, 1002), sha1Secret, time.Unix(30*1000, 0).UTC(), &drift)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, int64(2), drift.Offset)
+}
+
+func TestValidatorBeforeEpoch(t *testing.T) {
+	validator := NewValidator(&TotpOptions{T0: 100, Period: 30})
+
+	valid, err := validator.Validate("123456", sha1Secret, time.Unix(10, 0), &DriftState{})
+	assert.ErrorIs(t, err, common.ErrorTimeBeforeEpoch)
+	assert.False(t, valid)
+}
+
+func TestDriftStateSerialization(t *testing.T) {
+	data, err := json.Marshal(DriftState{Offset: -2})
+	require.NoError(t, err)
+	assert.Equal(t, `{"offset":-2}`, string(data))
+
+	var drift DriftState
+	require.NoError(t, json.Unmarshal(data, &drift))
+	assert.Equal(t, int64(-2), drift.Offset)
+}
+
+func TestValidatorValidateStep(t *testing.T) {
+	validator := NewValidator(NewDefaultTotpOptions())
+	drift := DriftState{}
+	now := time.Unix(30*1000, 0).UTC()
+
+	step, valid, err := validator.ValidateStep(codeAt(t, 999), sha1Secret, now, &drift)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, uint64(999), step)
+	assert.Equal(t, int64(-1), drift.Offset)
+
+	step, valid, err = validator.ValidateStep("000000", sha1Secret, now, &drift)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, uint64(0), step)
+}
+
+func TestValidatorEnforcesPolicy(t *testing.T) {
+	now := time.Unix(30*1000, 0).UTC()
+	validator := NewValidator(NewDefaultTotpOptions())
+	validator.Policy = otp.StrictPolicy()
+
+	// SHA-1 keys of authenticator apps pass the strict policy
+	valid, err := validator.Validate(codeAt(t, 1000), sha1Secret, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	validator.Options = &TotpOptions{Period: 30, Skew: 1, Algorithm: common.SHA3_256Algorithm}
+	_, err = validator.Validate(codeAt(t, 1000), sha1Secret, now, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "algorithm")
+	assert.NotContains(t, err.Error(), "secret")
+
+	options := &TotpOptions{Period: 120, PastSkew: 3, FutureSkew: 1, Algorithm: common.SHA256Algorithm}
+	err = options.CheckPolicy(otp.StrictPolicy())
+	assert.ErrorContains(t, err, "period")
+	assert.ErrorContains(t, err, "past_skew")
+	assert.NotContains(t, err.Error(), "future_skew")
+
+	validator = NewValidator(&TotpOptions{Period: 30, Skew: 1, CodeSize: common.EightDigits, Algorithm: common.SHA256Algorithm})
+	validator.Policy = otp.StrictPolicy()
+	code, err := GenerateCode(sha256Secret, now, validator.Options)
+	require.NoError(t, err)
+
+	valid, err = validator.Validate(code, sha256Secret, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidateEnforcesDefaultPolicy(t *testing.T) {
+	otp.SetPolicy(otp.StrictPolicy())
+	t.Cleanup(func() { otp.SetPolicy(nil) })
+	now := time.Unix(30*1000, 0).UTC()
+
+	_, err := Validate(codeAt(t, 1000), sha1Secret, now, &TotpOptions{Period: 120, Skew: 1})
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "period")
+
+	weak := base32.StdEncoding.EncodeToString([]byte("1234567890"))
+	code, err := GenerateCode(weak, now, nil)
+	require.NoError(t, err)
+	_, err = Validate(code, weak, now, nil)
+	assert.ErrorIs(t, err, common.ErrorPolicyViolation)
+	assert.ErrorContains(t, err, "secret")
+
+	valid, err := Validate(codeAt(t, 1000), sha1Secret, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidatorNormalizer(t *testing.T) {
+	now := time.Unix(30*1000, 0).UTC()
+	code := otp.DisplayCode(codeAt(t, 1000))
+	validator := NewValidator(nil)
+
+	valid, err := validator.Validate(code, sha1Secret, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	validator.Normalizer = &otp.CodeNormalizer{}
+	valid, err = validator.Validate(code, sha1Secret, now, nil)
+	assert.ErrorIs(t, err, common.ErrorWrongCodeSize)
+	assert.False(t, valid)
+}
+
+func TestValidatorContext(t *testing.T) {
+	var events []otp.Event
+	ctx := otp.ContextWithObserver(context.Background(), otp.ObserverFunc(func(_ context.Context, event otp.Event) {
+		events = append(events, event)
+	}))
+	validator := NewValidator(nil)
+	now := time.Unix(30*1000, 0).UTC()
+
+	valid, err := validator.ValidateContext(ctx, codeAt(t, 1000), sha1Secret, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	require.Len(t, events, 1)
+	assert.Equal(t, otp.SuccessOutcome, events[0].Outcome)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	valid, err = validator.ValidateContext(cancelled, codeAt(t, 1000), sha1Secret, now, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, valid)
+
+	valid, err = ValidateContext(cancelled, codeAt(t, 1000), sha1Secret, now, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, valid)
+
+	_, err = GenerateCodeContext(cancelled, sha1Secret, now, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,162 @@
+package txsign
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+)
+
+// canonicalVersion prefixes the canonical encoding so the layout can change
+// without old codes matching new transactions.
+const canonicalVersion = "txsign/1"
+
+const maxAmountDigits = 32
+
+// Transaction holds the details the user confirms, the code is only valid
+// for the same amount, currency, payee and reference.
+type Transaction struct {
+	// Amount is a non negative decimal using a dot as separator, e.g. "10.50".
+	Amount string `json:"amount"`
+	// Currency is the ISO 4217 code, e.g. "EUR".
+	Currency string `json:"currency"`
+	// Payee is the beneficiary account or name.
+	Payee string `json:"payee"`
+	// Reference optionally tells apart transactions with the same details.
+	Reference string `json:"reference,omitempty"`
+}
+
+// Normalize returns a copy of the transaction in canonical form, leading
+// zeros and trailing fraction zeros are removed from the amount, the
+// currency is upper cased and whitespace in the payee and reference is
+// collapsed.
+func (tx Transaction) Normalize() (Transaction, error) {
+	amount, err := canonicalAmount(tx.Amount)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(tx.Currency))
+	if len(currency) != 3 || strings.IndexFunc(currency, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return Transaction{}, common.ErrorInvalidTransaction.WithField("Currency")
+	}
+
+	payee := strings.Join(strings.Fields(tx.Payee), " ")
+	if payee == "" {
+		return Transaction{}, common.ErrorInvalidTransaction.WithField("Payee")
+	}
+
+	result := Transaction{
+		Amount:    amount,
+		Currency:  currency,
+		Payee:     payee,
+		Reference: strings.Join(strings.Fields(tx.Reference), " "),
+	}
+
+	return result, nil
+}
+
+// Canonical encodes the normalized transaction as length prefixed fields,
+// both the companion app and the server derive the code from it.
+func (tx Transaction) Canonical() ([]byte, error) {
+	normalized, err := tx.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []byte(canonicalVersion)
+	for _, value := range []string{normalized.Amount, normalized.Currency, normalized.Payee, normalized.Reference} {
+		result = binary.AppendUvarint(result, uint64(len(value)))
+		result = append(result, value...)
+	}
+
+	return result, nil
+}
+
+// GenerateCode derives the code the companion app shows next to the
+// transaction details, any binding in options is replaced by the
+// transaction.
+func GenerateCode(secret string, tx *Transaction, t time.Time, options *totp.TotpOptions) (string, error) {
+	return GenerateCodeContext(context.Background(), secret, tx, t, options)
+}
+
+func GenerateCodeContext(ctx context.Context, secret string, tx *Transaction, t time.Time, options *totp.TotpOptions) (string, error) {
+	bound, err := bind(tx, options)
+	if err != nil {
+		return "", err
+	}
+
+	return totp.GenerateCodeContext(ctx, secret, t, bound)
+}
+
+// Validate checks the code against the transaction the server is about to
+// execute, a code signed for other details is rejected.
+func Validate(code string, secret string, tx *Transaction, t time.Time, options *totp.TotpOptions) (bool, error) {
+	return ValidateContext(context.Background(), code, secret, tx, t, options)
+}
+
+func ValidateContext(ctx context.Context, code string, secret string, tx *Transaction, t time.Time, options *totp.TotpOptions) (bool, error) {
+	bound, err := bind(tx, options)
+	if err != nil {
+		return false, err
+	}
+
+	return totp.ValidateContext(ctx, code, secret, t, bound)
+}
+
+func bind(tx *Transaction, options *totp.TotpOptions) (*totp.TotpOptions, error) {
+	if tx == nil {
+		return nil, common.ErrorInvalidTransaction
+	}
+
+	if options == nil {
+		options = totp.NewDefaultTotpOptions()
+	}
+
+	canonical, err := tx.Canonical()
+	if err != nil {
+		return nil, err
+	}
+
+	result := *options
+	result.Binding = string(canonical)
+
+	return &result, nil
+}
+
+func canonicalAmount(amount string) (string, error) {
+	amount = strings.TrimSpace(amount)
+	whole, fraction, hasFraction := strings.Cut(amount, ".")
+	if whole == "" || (hasFraction && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return "", common.ErrorInvalidTransaction.WithField("Amount")
+	}
+
+	whole = strings.TrimLeft(whole, "0")
+	if whole == "" {
+		whole = "0"
+	}
+	fraction = strings.TrimRight(fraction, "0")
+
+	if len(whole)+len(fraction) > maxAmountDigits {
+		return "", common.ErrorInvalidTransaction.WithField("Amount")
+	}
+
+	if fraction == "" {
+		return whole, nil
+	}
+
+	return whole + "." + fraction, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
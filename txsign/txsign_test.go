@@ -0,0 +1,119 @@
+package txsign
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	secret = otp.NewRandomOtpSecret(20).Value()
+	now    = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tx     = &Transaction{Amount: "150.00", Currency: "EUR", Payee: "DE89 3704 0044 0532 0130 00"}
+)
+
+func TestNormalize(t *testing.T) {
+	normalized, err := Transaction{Amount: " 0150.50 ", Currency: "eur", Payee: "  DE89  3704 \t0044 "}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, Transaction{Amount: "150.5", Currency: "EUR", Payee: "DE89 3704 0044"}, normalized)
+
+	for amount, expected := range map[string]string{"0": "0", "000.000": "0", "10": "10", "10.0100": "10.01"} {
+		normalized, err := Transaction{Amount: amount, Currency: "EUR", Payee: "ACME"}.Normalize()
+		require.NoError(t, err, amount)
+		assert.Equal(t, expected, normalized.Amount, amount)
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	for name, tx := range map[string]Transaction{
+		"Amount":   {Amount: "-1", Currency: "EUR", Payee: "ACME"},
+		"Currency": {Amount: "1", Currency: "EURO", Payee: "ACME"},
+		"Payee":    {Amount: "1", Currency: "EUR", Payee: " "},
+	} {
+		_, err := tx.Normalize()
+		assert.ErrorIs(t, err, common.ErrorInvalidTransaction, name)
+
+		var otpErr *common.OtpError
+		require.ErrorAs(t, err, &otpErr)
+		assert.Equal(t, name, otpErr.Field)
+	}
+
+	for _, amount := range []string{"", ".5", "5.", "1,5", "1e3", "1.2.3"} {
+		_, err := Transaction{Amount: amount, Currency: "EUR", Payee: "ACME"}.Normalize()
+		assert.ErrorIs(t, err, common.ErrorInvalidTransaction, amount)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	expected, err := tx.Canonical()
+	require.NoError(t, err)
+
+	equivalent, err := Transaction{Amount: "150", Currency: "eur", Payee: "DE89 3704 0044  0532 0130 00"}.Canonical()
+	require.NoError(t, err)
+	assert.Equal(t, expected, equivalent)
+
+	// fields can not shift into each other
+	first, err := Transaction{Amount: "1", Currency: "EUR", Payee: "A", Reference: "B"}.Canonical()
+	require.NoError(t, err)
+	second, err := Transaction{Amount: "1", Currency: "EUR", Payee: "A B"}.Canonical()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestGenerateAndValidate(t *testing.T) {
+	code, err := GenerateCode(secret, tx, now, nil)
+	require.NoError(t, err)
+
+	valid, err := Validate(code, secret, &Transaction{Amount: "150", Currency: "eur", Payee: tx.Payee}, now, nil)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	for _, other := range []*Transaction{
+		{Amount: "1500", Currency: "EUR", Payee: tx.Payee},
+		{Amount: "150", Currency: "USD", Payee: tx.Payee},
+		{Amount: "150", Currency: "EUR", Payee: "GB29 NWBK 6016 1331 9268 19"},
+		{Amount: "150", Currency: "EUR", Payee: tx.Payee, Reference: "invoice-2"},
+	} {
+		valid, err := Validate(code, secret, other, now, nil)
+		require.NoError(t, err)
+		assert.False(t, valid, other)
+	}
+
+	// the plain TOTP code of the secret is not a transaction code
+	plain, err := totp.GenerateCode(secret, now, nil)
+	require.NoError(t, err)
+	valid, err = Validate(plain, secret, tx, now, nil)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestValidateWithOptions(t *testing.T) {
+	options := &totp.TotpOptions{Period: 60, Skew: 1, CodeSize: common.EightDigits, Algorithm: common.SHA256Algorithm, Binding: "login"}
+
+	code, err := GenerateCode(secret, tx, now, options)
+	require.NoError(t, err)
+	assert.Len(t, code, 8)
+	assert.Equal(t, "login", options.Binding)
+
+	valid, err := Validate(code, secret, tx, now.Add(time.Minute), options)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = Validate(code, secret, tx, now.Add(5*time.Minute), options)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestInvalidTransaction(t *testing.T) {
+	_, err := GenerateCode(secret, nil, now, nil)
+	assert.ErrorIs(t, err, common.ErrorInvalidTransaction)
+
+	valid, err := Validate("123456", secret, &Transaction{Amount: "1"}, now, nil)
+	assert.ErrorIs(t, err, common.ErrorInvalidTransaction)
+	assert.False(t, valid)
+}
@@ -0,0 +1,259 @@
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	fileVersion = 1
+	argon2id    = "argon2id"
+	keySize     = 32
+	saltSize    = 16
+
+	// the KDF parameters come from the file, they are capped so a crafted
+	// file can not make opening it use unbounded memory or time
+	maxKDFTime   = 16
+	maxKDFMemory = 1024 * 1024
+)
+
+// KDFParams are the argon2id parameters used to derive the file key from
+// the passphrase, Memory is in KiB.
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultKDFParams follows the RFC 9106 recommendation for memory
+// constrained environments, 64 MiB and three passes.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+}
+
+type kdfHeader struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	KDFParams
+}
+
+// header is authenticated but not encrypted, tampering with the parameters
+// fails the decryption.
+type header struct {
+	Version int       `json:"version"`
+	KDF     kdfHeader `json:"kdf"`
+}
+
+type envelope struct {
+	header
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+type contents struct {
+	Accounts []*Account `json:"accounts"`
+}
+
+// Seal encrypts the accounts with a key derived from the passphrase.
+func (v *Vault) Seal(passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, common.ErrorInvalidPassphrase.WithField("passphrase")
+	}
+
+	v.mu.RLock()
+	plaintext, err := json.Marshal(contents{Accounts: v.accounts})
+	params := v.KDF
+	v.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := envelope{
+		header: header{
+			Version: fileVersion,
+			KDF: kdfHeader{
+				Name:      argon2id,
+				Salt:      make([]byte, saltSize),
+				KDFParams: params,
+			},
+		},
+	}
+
+	if _, err := rand.Read(result.KDF.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAead(passphrase, result.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(result.Nonce); err != nil {
+		return nil, err
+	}
+
+	additionalData, err := json.Marshal(result.header)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Data = aead.Seal(nil, result.Nonce, plaintext, additionalData)
+
+	return json.Marshal(result)
+}
+
+// Unseal decrypts a vault sealed with the same passphrase, the KDF
+// parameters of the data are kept for the next Seal.
+func Unseal(data []byte, passphrase string) (*Vault, error) {
+	var sealed envelope
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("vault").WithCause(err)
+	}
+
+	if sealed.Version != fileVersion || sealed.KDF.Name != argon2id {
+		return nil, common.ErrorInvalidEncoding.WithField("version")
+	}
+
+	aead, err := newAead(passphrase, sealed.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	additionalData, err := json.Marshal(sealed.header)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return nil, common.ErrorInvalidEncoding.WithField("nonce")
+	}
+
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Data, additionalData)
+	if err != nil {
+		return nil, common.ErrorInvalidPassphrase.WithField("passphrase")
+	}
+
+	var result contents
+	if err := json.Unmarshal(plaintext, &result); err != nil {
+		return nil, common.ErrorInvalidEncoding.WithField("accounts").WithCause(err)
+	}
+
+	for _, account := range result.Accounts {
+		if account == nil || account.Key == nil {
+			return nil, common.ErrorInvalidEncoding.WithField("accounts")
+		}
+	}
+
+	vault := New()
+	vault.KDF = sealed.KDF.KDFParams
+	vault.accounts = result.Accounts
+
+	return vault, nil
+}
+
+// Save seals the vault and replaces the file at path, the file is only
+// readable by its owner.
+func (v *Vault) Save(path string, passphrase string) error {
+	return v.SaveContext(context.Background(), path, passphrase)
+}
+
+// SaveContext works like Save, the file is left untouched when ctx is done
+// before the sealed vault is written.
+func (v *Vault) SaveContext(ctx context.Context, path string, passphrase string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := v.Seal(passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func Open(path string, passphrase string) (*Vault, error) {
+	return OpenContext(context.Background(), path, passphrase)
+}
+
+func OpenContext(ctx context.Context, path string, passphrase string) (*Vault, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return Unseal(data, passphrase)
+}
+
+func newAead(passphrase string, kdf kdfHeader) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, common.ErrorInvalidPassphrase.WithField("passphrase")
+	}
+
+	if len(kdf.Salt) != saltSize || kdf.Threads == 0 {
+		return nil, common.ErrorInvalidEncoding.WithField("kdf")
+	}
+
+	if kdf.Time == 0 || kdf.Time > maxKDFTime {
+		return nil, common.ErrorInvalidEncoding.WithField("kdf.time").WithCause(fmt.Errorf("must be between 1 and %d", maxKDFTime))
+	}
+
+	if kdf.Memory == 0 || kdf.Memory > maxKDFMemory {
+		return nil, common.ErrorInvalidEncoding.WithField("kdf.memory").WithCause(fmt.Errorf("must be between 1 and %d KiB", maxKDFMemory))
+	}
+
+	key := argon2.IDKey([]byte(passphrase), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, keySize)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDF keeps the tests fast, it is far too weak for real use.
+var testKDF = KDFParams{Time: 1, Memory: 1024, Threads: 1}
+
+func TestSaveAndOpen(t *testing.T) {
+	vault, github, bank := newTestVault(t)
+	vault.KDF = testKDF
+	_, err := vault.Code(bank.ID, time.Now())
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "vault.json")
+	require.NoError(t, vault.Save(path, "correct horse"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), rfcSecret)
+	assert.NotContains(t, string(content), "octocat")
+
+	opened, err := Open(path, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, testKDF, opened.KDF)
+
+	accounts := opened.Accounts()
+	require.Len(t, accounts, 2)
+	assert.Equal(t, github.ID, accounts[0].ID)
+	assert.Equal(t, github.Key.String(), accounts[0].Key.String())
+	assert.Equal(t, []string{"personal", "money"}, accounts[1].Tags)
+	assert.Equal(t, uint64(1), accounts[1].Counter)
+
+	code, err := opened.Code(github.ID, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code.Code)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestOpenWithWrongPassphrase(t *testing.T) {
+	vault, _, _ := newTestVault(t)
+	vault.KDF = testKDF
+
+	data, err := vault.Seal("correct horse")
+	require.NoError(t, err)
+
+	_, err = Unseal(data, "battery staple")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+
+	_, err = Unseal(data, "")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+
+	_, err = vault.Seal("")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+}
+
+func TestUnsealDetectsTampering(t *testing.T) {
+	vault, _, _ := newTestVault(t)
+	vault.KDF = testKDF
+
+	data, err := vault.Seal("correct horse")
+	require.NoError(t, err)
+
+	var sealed map[string]any
+	require.NoError(t, json.Unmarshal(data, &sealed))
+	sealed["kdf"].(map[string]any)["time"] = 2
+	tampered, err := json.Marshal(sealed)
+	require.NoError(t, err)
+
+	_, err = Unseal(tampered, "correct horse")
+	assert.ErrorIs(t, err, common.ErrorInvalidPassphrase)
+
+	_, err = Unseal([]byte(`{"version":2}`), "correct horse")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+
+	_, err = Unseal([]byte(`not json`), "correct horse")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.json"), "correct horse")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestUnsealRejectsExcessiveKDFParams(t *testing.T) {
+	vault, _, _ := newTestVault(t)
+	vault.KDF = testKDF
+
+	data, err := vault.Seal("correct horse")
+	require.NoError(t, err)
+
+	tests := map[string]float64{
+		"time":    maxKDFTime + 1,
+		"memory":  maxKDFMemory + 1,
+		"threads": 0,
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			var sealed map[string]any
+			require.NoError(t, json.Unmarshal(data, &sealed))
+			sealed["kdf"].(map[string]any)[name] = value
+			tampered, err := json.Marshal(sealed)
+			require.NoError(t, err)
+
+			_, err = Unseal(tampered, "correct horse")
+			assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+		})
+	}
+
+	vault.KDF.Memory = maxKDFMemory + 1
+	_, err = vault.Seal("correct horse")
+	assert.ErrorIs(t, err, common.ErrorInvalidEncoding)
+}
+
+func TestSaveAndOpenContextCancelled(t *testing.T) {
+	vault, _, _ := newTestVault(t)
+	vault.KDF = testKDF
+	path := filepath.Join(t.TempDir(), "vault.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, vault.SaveContext(ctx, path, "correct horse"), context.Canceled)
+	_, err := os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, vault.Save(path, "correct horse"))
+	_, err = OpenContext(ctx, path, "correct horse")
+	assert.ErrorIs(t, err, context.Canceled)
+}
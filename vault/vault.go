@@ -0,0 +1,334 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+)
+
+// Account is a single key stored in the vault, Counter is the next HOTP
+// counter and is unused for TOTP keys.
+type Account struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Issuer    string      `json:"issuer"`
+	Key       *otp.OtpKey `json:"key"`
+	Tags      []string    `json:"tags,omitempty"`
+	Counter   uint64      `json:"counter,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// clone copies the account so callers can not change the vault state
+// without going through its methods, the key is immutable and shared.
+func (a *Account) clone() *Account {
+	result := *a
+	result.Tags = slices.Clone(a.Tags)
+
+	return &result
+}
+
+func (a *Account) HasTag(tag string) bool {
+	return slices.ContainsFunc(a.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
+
+// Code is a generated code, Remaining is the time left before a TOTP code
+// changes and Counter the counter a HOTP code was generated with.
+type Code struct {
+	AccountId string
+	Code      string
+	Remaining time.Duration
+	Counter   uint64
+}
+
+// Vault is an authenticator holding many accounts in a user defined order,
+// it is safe for concurrent use.
+type Vault struct {
+	// KDF is used to derive the file key when saving.
+	KDF      KDFParams
+	mu       sync.RWMutex
+	accounts []*Account
+}
+
+func New() *Vault {
+	result := Vault{
+		KDF: DefaultKDFParams(),
+	}
+
+	return &result
+}
+
+// Add stores the key as a new account at the end of the list, the account
+// name and issuer are taken from the key label.
+func (v *Vault) Add(key *otp.OtpKey, tags ...string) (*Account, error) {
+	if key == nil {
+		return nil, common.ErrorNilOtpKey.WithField("key")
+	}
+
+	// re-parsing validates the secret, digits, algorithm and period
+	parsed, err := otp.ParseKey(key.String())
+	if err != nil {
+		return nil, err
+	}
+
+	counter, err := parsed.Counter()
+	if err != nil {
+		return nil, err
+	}
+
+	name := parsed.UserId()
+	if name == "" {
+		name = strings.TrimPrefix(parsed.String(), "otpauth://")
+	}
+
+	account := Account{
+		ID:        newAccountId(),
+		Name:      name,
+		Issuer:    parsed.Issuer(),
+		Key:       parsed,
+		Tags:      normalizeTags(nil, tags),
+		Counter:   counter,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.accounts = append(v.accounts, &account)
+
+	return account.clone(), nil
+}
+
+// Get returns a copy of the account, use the vault methods to change it.
+func (v *Vault) Get(id string) (*Account, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	_, account, err := v.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return account.clone(), nil
+}
+
+func (v *Vault) Remove(id string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	i, _, err := v.find(id)
+	if err != nil {
+		return err
+	}
+
+	v.accounts = slices.Delete(v.accounts, i, i+1)
+
+	return nil
+}
+
+// Accounts returns copies of all the accounts in their display order.
+func (v *Vault) Accounts() []*Account {
+	return v.filter(func(*Account) bool {
+		return true
+	})
+}
+
+// Search returns the accounts whose name, issuer or tags contain the query,
+// ignoring case.
+func (v *Vault) Search(query string) []*Account {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	return v.filter(func(account *Account) bool {
+		if strings.Contains(strings.ToLower(account.Name), query) ||
+			strings.Contains(strings.ToLower(account.Issuer), query) {
+			return true
+		}
+
+		return slices.ContainsFunc(account.Tags, func(tag string) bool {
+			return strings.Contains(strings.ToLower(tag), query)
+		})
+	})
+}
+
+func (v *Vault) WithTag(tag string) []*Account {
+	return v.filter(func(account *Account) bool {
+		return account.HasTag(tag)
+	})
+}
+
+func (v *Vault) Tag(id string, tags ...string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, account, err := v.find(id)
+	if err != nil {
+		return err
+	}
+
+	account.Tags = normalizeTags(account.Tags, tags)
+
+	return nil
+}
+
+func (v *Vault) Untag(id string, tags ...string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, account, err := v.find(id)
+	if err != nil {
+		return err
+	}
+
+	account.Tags = slices.DeleteFunc(account.Tags, func(t string) bool {
+		return slices.ContainsFunc(tags, func(tag string) bool {
+			return strings.EqualFold(t, strings.TrimSpace(tag))
+		})
+	})
+
+	return nil
+}
+
+// Move places the account at index in the display order, an index past the
+// end moves it last.
+func (v *Vault) Move(id string, index int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	i, account, err := v.find(id)
+	if err != nil {
+		return err
+	}
+
+	v.accounts = slices.Delete(v.accounts, i, i+1)
+	index = min(max(index, 0), len(v.accounts))
+	v.accounts = slices.Insert(v.accounts, index, account)
+
+	return nil
+}
+
+// Code generates the current code of the account, for HOTP accounts every
+// call uses up the counter.
+func (v *Vault) Code(id string, t time.Time) (*Code, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, account, err := v.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := account.Key.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	result := Code{
+		AccountId: account.ID,
+	}
+
+	switch account.Key.Type() {
+	case "hotp":
+		result.Counter = account.Counter
+		result.Code, err = hotp.GenerateCode(account.Key.Secret(), account.Counter, options)
+		if err != nil {
+			return nil, err
+		}
+
+		account.Counter++
+	default:
+		period, err := account.Key.Period()
+		if err != nil {
+			return nil, err
+		}
+
+		totpOptions := totp.TotpOptions{
+			Period:    period,
+			CodeSize:  options.CodeSize,
+			Algorithm: options.Algorithm,
+		}
+
+		result.Code, err = totp.GenerateCode(account.Key.Secret(), t, &totpOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		step := time.Duration(period) * time.Second
+		result.Remaining = step - time.Duration(t.UnixNano()%int64(step))
+	}
+
+	return &result, nil
+}
+
+// Codes generates the current code of every TOTP account, HOTP accounts are
+// skipped as generating their code uses up the counter.
+func (v *Vault) Codes(t time.Time) ([]*Code, error) {
+	var result []*Code
+	for _, account := range v.Accounts() {
+		if account.Key.Type() == "hotp" {
+			continue
+		}
+
+		code, err := v.Code(account.ID, t)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, code)
+	}
+
+	return result, nil
+}
+
+func (v *Vault) find(id string) (int, *Account, error) {
+	for i, account := range v.accounts {
+		if account.ID == id {
+			return i, account, nil
+		}
+	}
+
+	return -1, nil, common.ErrorAccountNotFound.WithField("id")
+}
+
+func (v *Vault) filter(match func(account *Account) bool) []*Account {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var result []*Account
+	for _, account := range v.accounts {
+		if match(account) {
+			result = append(result, account.clone())
+		}
+	}
+
+	return result
+}
+
+func normalizeTags(existing []string, tags []string) []string {
+	result := slices.Clone(existing)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.ContainsFunc(result, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+
+		result = append(result, tag)
+	}
+
+	return result
+}
+
+func newAccountId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
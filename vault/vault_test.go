@@ -0,0 +1,234 @@
+package vault
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cjlapao/common-go-identity-otp/common"
+	"github.com/cjlapao/common-go-identity-otp/credential"
+	"github.com/cjlapao/common-go-identity-otp/hotp"
+	"github.com/cjlapao/common-go-identity-otp/otp"
+	"github.com/cjlapao/common-go-identity-otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func parseKey(t *testing.T, uri string) *otp.OtpKey {
+	key, err := otp.ParseKey(uri)
+	require.NoError(t, err)
+
+	return key
+}
+
+func newTestVault(t *testing.T) (*Vault, *Account, *Account) {
+	vault := New()
+
+	github, err := vault.Add(parseKey(t, "otpauth://totp/GitHub:octocat?issuer=GitHub&secret="+rfcSecret), "work")
+	require.NoError(t, err)
+
+	bank, err := vault.Add(parseKey(t, "otpauth://hotp/Bank:john?issuer=Bank&digits=8&secret="+rfcSecret), "personal", "money")
+	require.NoError(t, err)
+
+	return vault, github, bank
+}
+
+func TestAdd(t *testing.T) {
+	vault, github, bank := newTestVault(t)
+
+	assert.Equal(t, "octocat", github.Name)
+	assert.Equal(t, "GitHub", github.Issuer)
+	assert.Equal(t, []string{"work"}, github.Tags)
+	assert.NotEqual(t, github.ID, bank.ID)
+	assert.Equal(t, []*Account{github, bank}, vault.Accounts())
+
+	_, err := vault.Add(nil)
+	assert.ErrorIs(t, err, common.ErrorNilOtpKey)
+
+	invalid, err := otp.NewKeyFromUrl(url.URL{Scheme: "otpauth", Host: "totp", Path: "/foo:bar"})
+	require.NoError(t, err)
+	_, err = vault.Add(invalid)
+	assert.ErrorIs(t, err, common.ErrorInvalidSecret)
+}
+
+func TestTotpCode(t *testing.T) {
+	vault, github, _ := newTestVault(t)
+	now := time.Unix(59, 250*int64(time.Millisecond))
+
+	code, err := vault.Code(github.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code.Code)
+	assert.Equal(t, 750*time.Millisecond, code.Remaining)
+
+	code, err = vault.Code(github.ID, time.Unix(60, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, code.Remaining)
+}
+
+func TestTotpCodeWithPeriod(t *testing.T) {
+	vault := New()
+	account, err := vault.Add(parseKey(t, "otpauth://totp/foo:bar?period=60&secret="+rfcSecret))
+	require.NoError(t, err)
+
+	now := time.Unix(1111111109, 0)
+	code, err := vault.Code(account.ID, now)
+	require.NoError(t, err)
+
+	expected, err := totp.GenerateCode(rfcSecret, now, &totp.TotpOptions{Period: 60, CodeSize: common.SixDigits})
+	require.NoError(t, err)
+	assert.Equal(t, expected, code.Code)
+	assert.Equal(t, 31*time.Second, code.Remaining)
+}
+
+func TestHotpCodeIncrementsCounter(t *testing.T) {
+	vault, _, bank := newTestVault(t)
+
+	for counter := uint64(0); counter < 3; counter++ {
+		code, err := vault.Code(bank.ID, time.Now())
+		require.NoError(t, err)
+
+		expected, err := hotp.GenerateCode(rfcSecret, counter, &otp.OtpOptions{CodeSize: common.EightDigits})
+		require.NoError(t, err)
+		assert.Equal(t, expected, code.Code)
+		assert.Equal(t, counter, code.Counter)
+		assert.Zero(t, code.Remaining)
+	}
+
+	stored, err := vault.Get(bank.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), stored.Counter)
+	assert.Zero(t, bank.Counter)
+}
+
+func TestHotpCodeUsesKeyAlgorithm(t *testing.T) {
+	vault := New()
+	account, err := vault.Add(parseKey(t, "otpauth://hotp/Bank:john?algorithm=SHA256&secret="+rfcSecret))
+	require.NoError(t, err)
+
+	code, err := vault.Code(account.ID, time.Now())
+	require.NoError(t, err)
+
+	expected, err := otp.GenerateCode(rfcSecret, 0, &otp.OtpOptions{CodeSize: common.SixDigits, Algorithm: common.SHA256Algorithm})
+	require.NoError(t, err)
+	assert.Equal(t, expected, code.Code)
+
+	hotpCode, err := hotp.GenerateCode(rfcSecret, 0, &otp.OtpOptions{CodeSize: common.SixDigits, Algorithm: common.SHA256Algorithm})
+	require.NoError(t, err)
+	assert.Equal(t, hotpCode, code.Code)
+}
+
+func TestHotpCodeIsAcceptedByVerifier(t *testing.T) {
+	key := parseKey(t, "otpauth://hotp/Bank:john?algorithm=SHA256&secret="+rfcSecret)
+	vault := New()
+	account, err := vault.Add(key)
+	require.NoError(t, err)
+
+	enrolled, err := credential.NewCredential("bank", key)
+	require.NoError(t, err)
+	user := &credential.UserCredentials{UserId: "john", Credentials: []*credential.Credential{enrolled}}
+
+	for i := 0; i < 3; i++ {
+		code, err := vault.Code(account.ID, time.Now())
+		require.NoError(t, err)
+
+		verified, err := credential.NewVerifier().Verify(user, code.Code, time.Now())
+		require.NoError(t, err)
+		assert.Same(t, enrolled, verified)
+	}
+}
+
+func TestAccountsAreCopies(t *testing.T) {
+	vault, github, _ := newTestVault(t)
+
+	github.Counter = 42
+	github.Tags[0] = "changed"
+	for _, account := range append(vault.Accounts(), vault.Search("git")...) {
+		account.Name = "changed"
+		account.Tags = append(account.Tags[:0], "changed")
+	}
+
+	stored, err := vault.Get(github.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "octocat", stored.Name)
+	assert.Equal(t, []string{"work"}, stored.Tags)
+	assert.Zero(t, stored.Counter)
+}
+
+func TestCodesSkipsHotp(t *testing.T) {
+	vault, github, bank := newTestVault(t)
+
+	codes, err := vault.Codes(time.Unix(59, 0))
+	require.NoError(t, err)
+	require.Len(t, codes, 1)
+	assert.Equal(t, github.ID, codes[0].AccountId)
+
+	stored, err := vault.Get(bank.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.Counter)
+}
+
+func TestSearchAndTags(t *testing.T) {
+	vault, github, bank := newTestVault(t)
+
+	assert.Equal(t, []*Account{github}, vault.Search("git"))
+	assert.Equal(t, []*Account{bank}, vault.Search("JOHN"))
+	assert.Equal(t, []*Account{bank}, vault.Search("mon"))
+	assert.Len(t, vault.Search(""), 2)
+	assert.Empty(t, vault.Search("nothing"))
+
+	require.NoError(t, vault.Tag(github.ID, "Money", " shared ", ""))
+	github, err := vault.Get(github.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work", "Money", "shared"}, github.Tags)
+	assert.Equal(t, []*Account{github, bank}, vault.WithTag("money"))
+
+	require.NoError(t, vault.Untag(bank.ID, "MONEY"))
+	bank, err = vault.Get(bank.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"personal"}, bank.Tags)
+	assert.Equal(t, []*Account{github}, vault.WithTag("money"))
+
+	assert.ErrorIs(t, vault.Tag("missing", "foo"), common.ErrorAccountNotFound)
+	assert.ErrorIs(t, vault.Untag("missing", "foo"), common.ErrorAccountNotFound)
+}
+
+func TestMoveAndRemove(t *testing.T) {
+	vault, github, bank := newTestVault(t)
+	third, err := vault.Add(parseKey(t, "otpauth://totp/Third:me?secret="+rfcSecret))
+	require.NoError(t, err)
+
+	require.NoError(t, vault.Move(third.ID, 0))
+	assert.Equal(t, []*Account{third, github, bank}, vault.Accounts())
+
+	require.NoError(t, vault.Move(third.ID, 10))
+	assert.Equal(t, []*Account{github, bank, third}, vault.Accounts())
+
+	require.NoError(t, vault.Move(bank.ID, -1))
+	assert.Equal(t, []*Account{bank, github, third}, vault.Accounts())
+
+	require.NoError(t, vault.Remove(github.ID))
+	assert.Equal(t, []*Account{bank, third}, vault.Accounts())
+
+	_, err = vault.Get(github.ID)
+	assert.ErrorIs(t, err, common.ErrorAccountNotFound)
+	assert.ErrorIs(t, vault.Remove(github.ID), common.ErrorAccountNotFound)
+	assert.ErrorIs(t, vault.Move(github.ID, 0), common.ErrorAccountNotFound)
+
+	_, err = vault.Code(github.ID, time.Now())
+	assert.ErrorIs(t, err, common.ErrorAccountNotFound)
+}
+
+func TestAddKeepsHotpCounter(t *testing.T) {
+	vault := New()
+	account, err := vault.Add(parseKey(t, "otpauth://hotp/Bank:john?counter=5&secret="+rfcSecret))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), account.Counter)
+
+	code, err := vault.Code(account.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), code.Counter)
+	assert.Equal(t, "254676", code.Code)
+}